@@ -7,6 +7,7 @@ import (
 	"github.com/lib/pq"
 	db "github.com/thehaung/simplebank/db/sqlc"
 	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/roleutil"
 	"net/http"
 )
 
@@ -69,7 +70,7 @@ func (s *Server) getAccount(ctx *gin.Context) {
 	}
 
 	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
+	if account.Owner != authPayload.Username && !roleutil.CanViewAnyAccount(authPayload.Role) {
 		err := errors.New("account doesn't belong to the authenticated user")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
@@ -105,3 +106,56 @@ func (s *Server) listAccount(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, accounts)
 }
+
+// isViewableAccount loads an account and makes sure the authenticated user may read it,
+// either because it owns the account or because its role can view any account
+func (s *Server) isViewableAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := s.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username && !roleutil.CanViewAnyAccount(authPayload.Role) {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+type listAllAccountsRequest struct {
+	Owner    string `form:"owner" binding:"omitempty,alphanum"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listAllAccounts lists the accounts of every user, optionally filtered by owner, for bankers and admins
+func (s *Server) listAllAccounts(ctx *gin.Context) {
+	var req listAllAccountsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ListAllAccountsParams{
+		Owner:       sql.NullString{String: req.Owner, Valid: len(req.Owner) > 0},
+		LimitCount:  req.PageSize,
+		OffsetCount: (req.PageID - 1) * req.PageSize,
+	}
+
+	accounts, err := s.store.ListAllAccounts(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accounts)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"net/http"
+	"time"
+)
+
+const _sortOrderAsc = "asc"
+
+type accountHistoryUri struct {
+	AccountID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type listAccountHistoryRequest struct {
+	PageID    int32     `form:"page_id" binding:"required,min=1"`
+	PageSize  int32     `form:"page_size" binding:"required,min=5,max=10"`
+	FromTime  time.Time `form:"from_time"`
+	ToTime    time.Time `form:"to_time" binding:"omitempty,gtfield=FromTime"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=incoming outgoing"`
+	SortBy    string    `form:"sort_by" binding:"omitempty,oneof=created_at amount"`
+	Order     string    `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+// sortDesc lists the newest history first unless asked otherwise
+func (r listAccountHistoryRequest) sortDesc() bool {
+	return r.Order != _sortOrderAsc
+}
+
+func (s *Server) listAccountEntries(ctx *gin.Context) {
+	var uri accountHistoryUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listAccountHistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.isViewableAccount(ctx, uri.AccountID)
+	if !valid {
+		return
+	}
+
+	arg := db.ListAccountEntriesParams{
+		AccountID:   account.ID,
+		FromTime:    nullTime(req.FromTime),
+		ToTime:      nullTime(req.ToTime),
+		Direction:   req.Direction,
+		SortBy:      req.SortBy,
+		SortDesc:    req.sortDesc(),
+		LimitCount:  req.PageSize,
+		OffsetCount: (req.PageID - 1) * req.PageSize,
+	}
+
+	entries, err := s.store.ListAccountEntries(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entries)
+}
+
+func (s *Server) listAccountTransfers(ctx *gin.Context) {
+	var uri accountHistoryUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listAccountHistoryRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := s.isViewableAccount(ctx, uri.AccountID)
+	if !valid {
+		return
+	}
+
+	arg := db.ListAccountTransfersParams{
+		AccountID:   account.ID,
+		FromTime:    nullTime(req.FromTime),
+		ToTime:      nullTime(req.ToTime),
+		Direction:   req.Direction,
+		SortBy:      req.SortBy,
+		SortDesc:    req.sortDesc(),
+		LimitCount:  req.PageSize,
+		OffsetCount: (req.PageID - 1) * req.PageSize,
+	}
+
+	transfers, err := s.store.ListAccountTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfers)
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
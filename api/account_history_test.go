@@ -0,0 +1,195 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestListAccountEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	fromTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	toTime := time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name          string
+		Username      string
+		Query         url.Values
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name:     "OK",
+			Username: user.Username,
+			Query: url.Values{
+				"page_id":   {"2"},
+				"page_size": {"5"},
+				"from_time": {fromTime.Format(time.RFC3339)},
+				"to_time":   {toTime.Format(time.RFC3339)},
+				"direction": {"incoming"},
+				"sort_by":   {"amount"},
+				"order":     {"asc"},
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.ListAccountEntriesParams{
+					AccountID:   account.ID,
+					FromTime:    sql.NullTime{Time: fromTime, Valid: true},
+					ToTime:      sql.NullTime{Time: toTime, Valid: true},
+					Direction:   "incoming",
+					SortBy:      "amount",
+					SortDesc:    false,
+					LimitCount:  5,
+					OffsetCount: 5,
+				}
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.Entry{}, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name:     "DefaultsToNewestFirst",
+			Username: user.Username,
+			Query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {"5"},
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.ListAccountEntriesParams{
+					AccountID:  account.ID,
+					SortDesc:   true,
+					LimitCount: 5,
+				}
+				store.EXPECT().
+					ListAccountEntries(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.Entry{}, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name:     "InvalidDirection",
+			Username: user.Username,
+			Query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {"5"},
+				"direction": {"sideways"},
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			Name:     "InvalidTimeRange",
+			Username: user.Username,
+			Query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {"5"},
+				"from_time": {toTime.Format(time.RFC3339)},
+				"to_time":   {fromTime.Format(time.RFC3339)},
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			Name:     "UnauthorizedUser",
+			Username: otherUser.Username,
+			Query: url.Values{
+				"page_id":   {"1"},
+				"page_size": {"5"},
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/accounts/%d/entries?%s", account.ID, tc.Query.Encode())
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, _authorizationHeaderBearer, tc.Username, roleutil.Depositor, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
+
+func TestListAccountTransfersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	arg := db.ListAccountTransfersParams{
+		AccountID:  account.ID,
+		Direction:  "outgoing",
+		SortDesc:   true,
+		LimitCount: 5,
+	}
+	store.EXPECT().
+		ListAccountTransfers(gomock.Any(), gomock.Eq(arg)).
+		Times(1).
+		Return([]db.Transfer{}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	path := fmt.Sprintf("/accounts/%d/transfers?page_id=1&page_size=5&direction=outgoing", account.ID)
+	request, err := http.NewRequest(http.MethodGet, path, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
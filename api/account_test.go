@@ -12,6 +12,7 @@ import (
 	db "github.com/thehaung/simplebank/db/sqlc"
 	"github.com/thehaung/simplebank/token"
 	"github.com/thehaung/simplebank/util/randutil"
+	"github.com/thehaung/simplebank/util/roleutil"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -34,7 +35,7 @@ func TestGetAccountAPI(t *testing.T) {
 			Name:      "InvalidID",
 			AccountID: -1,
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				// build stubs
@@ -51,7 +52,7 @@ func TestGetAccountAPI(t *testing.T) {
 			Name:      "OK",
 			AccountID: account.ID,
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				// build stubs
@@ -70,7 +71,7 @@ func TestGetAccountAPI(t *testing.T) {
 			Name:      "NotFound",
 			AccountID: account.ID,
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				// build stubs
@@ -88,7 +89,7 @@ func TestGetAccountAPI(t *testing.T) {
 			Name:      "InternalError",
 			AccountID: account.ID,
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				// build stubs
@@ -118,6 +119,39 @@ func TestGetAccountAPI(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			Name:      "OtherDepositor",
+			AccountID: account.ID,
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, "other", roleutil.Depositor, time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name:      "Banker",
+			AccountID: account.ID,
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, "banker", roleutil.Banker, time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -191,7 +225,7 @@ func TestListAccount(t *testing.T) {
 				PageSize: 9,
 			},
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -210,7 +244,7 @@ func TestListAccount(t *testing.T) {
 				PageSize: 9999,
 			},
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -229,7 +263,7 @@ func TestListAccount(t *testing.T) {
 				PageSize: 5,
 			},
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				arg := db.ListAccountsParams{
@@ -255,7 +289,7 @@ func TestListAccount(t *testing.T) {
 				PageSize: 5,
 			},
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -344,7 +378,7 @@ func TestCreateAccount(t *testing.T) {
 			Name: "BadRequest",
 			Body: gin.H{},
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -361,7 +395,7 @@ func TestCreateAccount(t *testing.T) {
 				"currency": "JP",
 			},
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -379,7 +413,7 @@ func TestCreateAccount(t *testing.T) {
 				"currency": "USD",
 			},
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -397,7 +431,7 @@ func TestCreateAccount(t *testing.T) {
 				"currency": "USD",
 			},
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
 			},
 			BuildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
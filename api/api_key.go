@@ -0,0 +1,155 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/apikeyutil"
+	"net/http"
+	"time"
+)
+
+type apiKeyResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// newApiKeyResponse leaves the hashed key out
+func newApiKeyResponse(apiKey db.ApiKey) apiKeyResponse {
+	rsp := apiKeyResponse{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+	}
+
+	if apiKey.ExpiresAt.Valid {
+		rsp.ExpiresAt = &apiKey.ExpiresAt.Time
+	}
+
+	if apiKey.RevokedAt.Valid {
+		rsp.RevokedAt = &apiKey.RevokedAt.Time
+	}
+
+	return rsp
+}
+
+type createApiKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=64"`
+	Scopes    []string   `json:"scopes" binding:"omitempty,unique,dive,scope"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type createApiKeyResponse struct {
+	Key    string         `json:"key"`
+	ApiKey apiKeyResponse `json:"api_key"`
+}
+
+// createApiKey creates a key for the authenticated user, the key itself is only returned here.
+// It requires a full access token, a key couldn't otherwise be used to mint keys that never expire.
+func (s *Server) createApiKey(ctx *gin.Context) {
+	var req createApiKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		err := errors.New("expires_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+	scopes := req.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	key, prefix, err := apikeyutil.Generate()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.CreateApiKeyParams{
+		ID:        uuid.New(),
+		Username:  authPayload.Username,
+		Name:      req.Name,
+		Prefix:    prefix,
+		HashedKey: apikeyutil.Hash(key),
+		Scopes:    scopes,
+	}
+
+	if req.ExpiresAt != nil {
+		arg.ExpiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	apiKey, err := s.store.CreateApiKey(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, createApiKeyResponse{
+		Key:    key,
+		ApiKey: newApiKeyResponse(apiKey),
+	})
+}
+
+func (s *Server) listApiKeys(ctx *gin.Context) {
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+	apiKeys, err := s.store.ListApiKeys(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]apiKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		rsp[i] = newApiKeyResponse(apiKey)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type revokeApiKeyRequest struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+func (s *Server) revokeApiKey(ctx *gin.Context) {
+	var req revokeApiKeyRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+	apiKey, err := s.store.RevokeApiKey(ctx, db.RevokeApiKeyParams{
+		ID:       uuid.MustParse(req.ID),
+		Username: authPayload.Username,
+	})
+	if err != nil {
+		// a key of another user or already revoked is reported as missing
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newApiKeyResponse(apiKey))
+}
@@ -0,0 +1,349 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/apikeyutil"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func randomApiKey(t *testing.T, username string) (string, db.GetApiKeyByPrefixRow) {
+	key, prefix, err := apikeyutil.Generate()
+	require.NoError(t, err)
+
+	return key, db.GetApiKeyByPrefixRow{
+		ID:        uuid.New(),
+		Username:  username,
+		Name:      "batch",
+		Prefix:    prefix,
+		HashedKey: apikeyutil.Hash(key),
+		Scopes:    []string{token.ScopeAccountsRead},
+		CreatedAt: time.Now(),
+		Role:      roleutil.Depositor,
+	}
+}
+
+func TestAuthMiddlewareApiKey(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		Name          string
+		BuildStubs    func(store *mockdb.MockStore, key string, apiKey db.GetApiKeyByPrefixRow) string
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder, apiKey db.GetApiKeyByPrefixRow)
+	}{
+		{
+			Name: "OK",
+			BuildStubs: func(store *mockdb.MockStore, key string, apiKey db.GetApiKeyByPrefixRow) string {
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				return key
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, apiKey db.GetApiKeyByPrefixRow) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var payload token.Payload
+				err := json.Unmarshal(recorder.Body.Bytes(), &payload)
+				require.NoError(t, err)
+				require.Equal(t, apiKey.ID, payload.ID)
+				require.Equal(t, apiKey.Username, payload.Username)
+				require.Equal(t, apiKey.Role, payload.Role)
+				require.Equal(t, apiKey.Scopes, payload.Scopes)
+			},
+		},
+		{
+			Name: "WrongSecret",
+			BuildStubs: func(store *mockdb.MockStore, key string, apiKey db.GetApiKeyByPrefixRow) string {
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				return key + "x"
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, apiKey db.GetApiKeyByPrefixRow) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "UnknownKey",
+			BuildStubs: func(store *mockdb.MockStore, key string, apiKey db.GetApiKeyByPrefixRow) string {
+				store.EXPECT().
+					GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).
+					Times(1).
+					Return(db.GetApiKeyByPrefixRow{}, sql.ErrNoRows)
+				return key
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, apiKey db.GetApiKeyByPrefixRow) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "MalformedKey",
+			BuildStubs: func(store *mockdb.MockStore, key string, apiKey db.GetApiKeyByPrefixRow) string {
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Any()).Times(0)
+				return "malformed"
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, apiKey db.GetApiKeyByPrefixRow) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "RevokedKey",
+			BuildStubs: func(store *mockdb.MockStore, key string, apiKey db.GetApiKeyByPrefixRow) string {
+				apiKey.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				return key
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, apiKey db.GetApiKeyByPrefixRow) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "ExpiredKey",
+			BuildStubs: func(store *mockdb.MockStore, key string, apiKey db.GetApiKeyByPrefixRow) string {
+				apiKey.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				return key
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, apiKey db.GetApiKeyByPrefixRow) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			key, apiKey := randomApiKey(t, user.Username)
+
+			store := mockdb.NewMockStore(ctrl)
+			presentedKey := tc.BuildStubs(store, key, apiKey)
+
+			server := newTestServer(t, store)
+
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revocations, server.store),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, ctx.MustGet(_authorizationPayloadKey))
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("ApiKey %s", presentedKey))
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder, apiKey)
+		})
+	}
+}
+
+func TestCreateApiKeyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		Name          string
+		Body          gin.H
+		Scopes        []string
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "OK",
+			Body: gin.H{
+				"name":   "batch",
+				"scopes": []string{token.ScopeAccountsRead},
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateApiKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateApiKeyParams) (db.ApiKey, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, []string{token.ScopeAccountsRead}, arg.Scopes)
+						require.False(t, arg.ExpiresAt.Valid)
+						return db.ApiKey{
+							ID:        arg.ID,
+							Username:  arg.Username,
+							Name:      arg.Name,
+							Prefix:    arg.Prefix,
+							HashedKey: arg.HashedKey,
+							Scopes:    arg.Scopes,
+						}, nil
+					})
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var rsp createApiKeyResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+
+				prefix, err := apikeyutil.Prefix(rsp.Key)
+				require.NoError(t, err)
+				require.Equal(t, prefix, rsp.ApiKey.Prefix)
+				require.NotContains(t, recorder.Body.String(), "hashed_key")
+			},
+		},
+		{
+			Name: "ScopedToken",
+			Body: gin.H{
+				"name":   "batch",
+				"scopes": []string{token.ScopeAccountsRead},
+			},
+			Scopes: []string{token.ScopeAccountsRead},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateApiKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			Name: "InvalidScope",
+			Body: gin.H{
+				"name":   "batch",
+				"scopes": []string{"everything"},
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateApiKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			Name: "ExpiresInThePast",
+			Body: gin.H{
+				"name":       "batch",
+				"expires_at": time.Now().Add(-time.Hour),
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateApiKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.Body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/api-keys", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			accessToken, _, err := server.tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Minute, tc.Scopes...)
+			require.NoError(t, err)
+
+			request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationHeaderBearer, accessToken))
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
+
+func TestRevokeApiKeyAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	apiKeyID := uuid.New()
+
+	testCases := []struct {
+		Name          string
+		SetupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker, store *mockdb.MockStore)
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "OK",
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, store *mockdb.MockStore) {
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeApiKey(gomock.Any(), gomock.Eq(db.RevokeApiKeyParams{ID: apiKeyID, Username: user.Username})).
+					Times(1).
+					Return(db.ApiKey{ID: apiKeyID, RevokedAt: sql.NullTime{Time: time.Now(), Valid: true}}, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name: "NotFound",
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, store *mockdb.MockStore) {
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevokeApiKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			Name: "ApiKey",
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, store *mockdb.MockStore) {
+				key, apiKey := randomApiKey(t, user.Username)
+				apiKey.Scopes = []string{}
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("ApiKey %s", key))
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeApiKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/api-keys/%s", apiKeyID), nil)
+			require.NoError(t, err)
+
+			tc.SetupAuth(t, request, server.tokenMaker, store)
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
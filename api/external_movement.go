@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"net/http"
+)
+
+type externalMovementUri struct {
+	AccountID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type externalMovementRequest struct {
+	Amount    int64  `json:"amount" binding:"required,gt=0"`
+	Currency  string `json:"currency" binding:"required,currency"`
+	Source    string `json:"source" binding:"required,max=255"`
+	Reference string `json:"reference" binding:"max=255"`
+	Channel   string `json:"channel" binding:"required,oneof=cash card bank_transfer"`
+}
+
+func (s *Server) createDeposit(ctx *gin.Context) {
+	arg, valid := s.bindExternalMovement(ctx)
+	if !valid {
+		return
+	}
+
+	result, err := s.store.DepositTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrAccountClosed) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorCodeResponse(_errorCodeAccountClosed, err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
+
+func (s *Server) createWithdrawal(ctx *gin.Context) {
+	arg, valid := s.bindExternalMovement(ctx)
+	if !valid {
+		return
+	}
+
+	result, err := s.store.WithdrawTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorCodeResponse(_errorCodeInsufficientFunds, err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
+
+// bindExternalMovement parses a deposit or withdrawal request
+// and checks that the account exists, uses the requested currency and belongs to the authenticated user
+func (s *Server) bindExternalMovement(ctx *gin.Context) (db.ExternalMovementTxParams, bool) {
+	var uri externalMovementUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.ExternalMovementTxParams{}, false
+	}
+
+	var req externalMovementRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.ExternalMovementTxParams{}, false
+	}
+
+	account, valid := s.isValidAccount(ctx, uri.AccountID, req.Currency)
+	if !valid {
+		return db.ExternalMovementTxParams{}, false
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return db.ExternalMovementTxParams{}, false
+	}
+
+	arg := db.ExternalMovementTxParams{
+		AccountID: account.ID,
+		Amount:    req.Amount,
+		Source:    req.Source,
+		Reference: req.Reference,
+		Channel:   req.Channel,
+	}
+
+	return arg, true
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"net/http"
+)
+
+const (
+	_errorCodeFxQuoteExpired = "fx_quote_expired"
+	_errorCodeFxQuoteUsed    = "fx_quote_used"
+)
+
+type createFxQuoteRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string `json:"to_currency" binding:"required,currency,nefield=FromCurrency"`
+	Amount       int64  `json:"amount" binding:"required,gt=0"`
+}
+
+func (s *Server) createFxQuote(ctx *gin.Context) {
+	var req createFxQuoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+	arg := db.QuoteFxParams{
+		Username:     authPayload.Username,
+		FromCurrency: req.FromCurrency,
+		ToCurrency:   req.ToCurrency,
+		FromAmount:   req.Amount,
+		Duration:     s.cfg.FxQuoteDuration,
+	}
+
+	quote, err := s.store.QuoteFx(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = fmt.Errorf("no fx rate available for %s/%s", req.FromCurrency, req.ToCurrency)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, quote)
+}
+
+// createFxTransfer executes a transfer between accounts of different currencies at the rate locked by a quote
+func (s *Server) createFxTransfer(ctx *gin.Context, req transferRequest) {
+	quoteID, err := uuid.Parse(req.QuoteID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	quote, err := s.store.GetFxQuote(ctx, quoteID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+	if quote.Username != authPayload.Username {
+		err = errors.New("fx quote doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if quote.FromCurrency != req.Currency || quote.FromAmount != req.Amount {
+		err = fmt.Errorf("transfer doesn't match fx quote: %d %s", quote.FromAmount, quote.FromCurrency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := s.isValidAccount(ctx, req.FromAccountID, quote.FromCurrency)
+	if !valid {
+		return
+	}
+
+	if fromAccount.Owner != authPayload.Username {
+		err = errors.New("from account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	_, valid = s.isValidAccount(ctx, req.ToAccountID, quote.ToCurrency)
+	if !valid {
+		return
+	}
+
+	arg := db.FxTransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   req.ToAccountID,
+		QuoteID:       quote.ID,
+	}
+
+	result, err := s.store.FxTransferTx(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrInsufficientFunds):
+			ctx.JSON(http.StatusUnprocessableEntity, errorCodeResponse(_errorCodeInsufficientFunds, err))
+		case errors.Is(err, db.ErrAccountClosed):
+			ctx.JSON(http.StatusUnprocessableEntity, errorCodeResponse(_errorCodeAccountClosed, err))
+		case errors.Is(err, db.ErrFxQuoteExpired):
+			ctx.JSON(http.StatusUnprocessableEntity, errorCodeResponse(_errorCodeFxQuoteExpired, err))
+		case errors.Is(err, db.ErrFxQuoteUsed):
+			ctx.JSON(http.StatusConflict, errorCodeResponse(_errorCodeFxQuoteUsed, err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
@@ -6,27 +6,60 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/thehaung/simplebank/config"
 	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/lockout"
+	"github.com/thehaung/simplebank/notify"
+	"github.com/thehaung/simplebank/passwordpolicy"
+	"github.com/thehaung/simplebank/revocation"
 	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/hashutil"
+	"github.com/thehaung/simplebank/util/roleutil"
 )
 
 type Server struct {
-	cfg        *config.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
+	cfg          *config.Config
+	store        db.Store
+	tokenMaker   token.Maker
+	revocations  *revocation.List
+	loginGuard   *lockout.Guard
+	notifier     notify.Notifier
+	hasher       hashutil.Hasher
+	passwords    *passwordpolicy.Policy
+	oauthClients map[string]string
+	router       *gin.Engine
 }
 
 func NewHttpServer(cfg *config.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewJwtMaker(cfg.TokenSymmetricKey)
+	tokenMaker, err := token.NewMakerFromConfig(cfg)
 
 	if err != nil {
 		return nil, err
 	}
 
+	oauthClients, err := parseOAuthClients(cfg.OAuthClients)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier, err := notify.NewNotifierFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher, err := hashutil.NewHasherFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	server := &Server{
-		store:      store,
-		tokenMaker: tokenMaker,
-		cfg:        cfg,
+		store:        store,
+		tokenMaker:   tokenMaker,
+		revocations:  revocation.NewList(store, cfg.RevocationCacheTTL),
+		loginGuard:   lockout.NewGuardFromConfig(store, cfg),
+		notifier:     notifier,
+		hasher:       hasher,
+		passwords:    passwordpolicy.NewFromConfig(cfg),
+		oauthClients: oauthClients,
+		cfg:          cfg,
 	}
 
 	v, ok := binding.Validator.Engine().(*validator.Validate)
@@ -35,6 +68,21 @@ func NewHttpServer(cfg *config.Config, store db.Store) (*Server, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		err = v.RegisterValidation("schedule", validSchedule)
+		if err != nil {
+			return nil, err
+		}
+
+		err = v.RegisterValidation("role", validRole)
+		if err != nil {
+			return nil, err
+		}
+
+		err = v.RegisterValidation("scope", validScope)
+		if err != nil {
+			return nil, err
+		}
 	}
 	server.registerRouter()
 
@@ -46,14 +94,67 @@ func (s *Server) registerRouter() {
 
 	router.POST("/users", s.createUser)
 	router.POST("/users/login", s.loginUser)
+	router.POST("/users/login/mfa", s.loginUserMfa)
+	router.POST("/users/password-reset", s.requestPasswordReset)
+	router.POST("/users/password-reset/confirm", s.confirmPasswordReset)
+	router.GET("/users/verify-email", s.verifyEmail)
 	router.POST("/token/renew_access", s.renewAccessToken)
+	router.GET("/.well-known/jwks.json", s.getJwks)
+
+	oauthRoutes := router.Group("/oauth").Use(clientCredentialsMiddleware(s.oauthClients))
+	oauthRoutes.POST("/introspect", s.introspectToken)
+	oauthRoutes.POST("/revoke", s.revokeOAuthToken)
+
+	authRoutes := router.Group("/").Use(authMiddleware(s.tokenMaker, s.revocations, s.store))
+	fullAccess := requireFullAccess()
+
+	authRoutes.POST("/users/logout", s.logoutUser)
+	authRoutes.POST("/users/logout-all", fullAccess, s.logoutAllSessions)
+	authRoutes.GET("/users/me", s.getCurrentUser)
+	authRoutes.PATCH("/users/me", fullAccess, s.updateCurrentUser)
+	authRoutes.DELETE("/users/me", fullAccess, s.closeCurrentUser)
+	authRoutes.PUT("/users/me/password", fullAccess, s.changePassword)
+	authRoutes.POST("/users/me/verify-email", s.resendVerifyEmail)
+	authRoutes.POST("/users/me/totp", fullAccess, s.enrollTotp)
+	authRoutes.POST("/users/me/totp/confirm", fullAccess, s.confirmTotp)
+	authRoutes.GET("/sessions", s.listSessions)
+	authRoutes.DELETE("/sessions/:id", fullAccess, s.deleteSession)
+	authRoutes.POST("/api-keys", fullAccess, s.createApiKey)
+	authRoutes.GET("/api-keys", s.listApiKeys)
+	authRoutes.DELETE("/api-keys/:id", fullAccess, s.revokeApiKey)
 
-	authRoutes := router.Group("/").Use(authMiddleware(s.tokenMaker))
-	authRoutes.GET("/accounts", s.listAccount)
-	authRoutes.GET("/accounts/:id", s.getAccount)
-	authRoutes.POST("/accounts", s.createAccount)
+	idempotency := idempotencyMiddleware(s.store, s.cfg.IdempotencyKeyDuration)
+	readAccounts := requireScope(token.ScopeAccountsRead)
+	writeAccounts := requireScope(token.ScopeAccountsWrite)
+	writeTransfers := requireScope(token.ScopeTransfersWrite)
+	verifiedEmail := requireVerifiedEmail(s.cfg, s.store)
 
-	authRoutes.POST("/transfers", s.createTransfer)
+	authRoutes.GET("/accounts", readAccounts, s.listAccount)
+	authRoutes.GET("/accounts/:id", readAccounts, s.getAccount)
+	authRoutes.POST("/accounts", writeAccounts, verifiedEmail, s.createAccount)
+	authRoutes.GET("/accounts/:id/entries", readAccounts, s.listAccountEntries)
+	authRoutes.GET("/accounts/:id/transfers", readAccounts, s.listAccountTransfers)
+	authRoutes.POST("/accounts/:id/deposits", writeAccounts, idempotency, s.createDeposit)
+	authRoutes.POST("/accounts/:id/withdrawals", writeAccounts, idempotency, s.createWithdrawal)
+
+	authRoutes.POST("/fx/quotes", writeTransfers, s.createFxQuote)
+	authRoutes.POST("/transfers", writeTransfers, verifiedEmail, idempotency, s.createTransfer)
+	authRoutes.POST("/transfers/:id/reversal", writeTransfers, idempotency, s.reverseTransfer)
+
+	authRoutes.POST("/scheduled-transfers", writeTransfers, verifiedEmail, s.createScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers", readAccounts, s.listScheduledTransfers)
+	authRoutes.GET("/scheduled-transfers/:id", readAccounts, s.getScheduledTransfer)
+	authRoutes.PATCH("/scheduled-transfers/:id", writeTransfers, s.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled-transfers/:id", writeTransfers, s.deleteScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers/:id/runs", readAccounts, s.listScheduledTransferRuns)
+
+	adminRoutes := router.Group("/admin").Use(authMiddleware(s.tokenMaker, s.revocations, s.store), requireFullAccess())
+	adminRoutes.GET("/accounts", requireRole(roleutil.Banker, roleutil.Admin), s.listAllAccounts)
+	adminRoutes.PATCH("/users/:username", requireRole(roleutil.Admin), s.adminUpdateUser)
+	adminRoutes.DELETE("/users/:username", requireRole(roleutil.Admin), s.adminCloseUser)
+	adminRoutes.PUT("/users/:username/role", requireRole(roleutil.Admin), s.updateUserRole)
+	adminRoutes.DELETE("/users/:username/lockout", requireRole(roleutil.Admin), s.unlockUser)
+	adminRoutes.POST("/tokens/revoke", requireRole(roleutil.Admin), s.revokeToken)
 
 	s.router = router
 }
@@ -65,3 +166,19 @@ func (s *Server) Start(address string) error {
 func errorResponse(err error) gin.H {
 	return gin.H{"errorMessage": err.Error()}
 }
+
+type fieldViolation struct {
+	Field       string `json:"field"`
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+}
+
+// fieldViolationsResponse tells which fields of the request were refused and why, next to a stable code
+func fieldViolationsResponse(code string, err error, violations []fieldViolation) gin.H {
+	return gin.H{"errorCode": code, "errorMessage": err.Error(), "fieldViolations": violations}
+}
+
+// errorCodeResponse adds a stable, machine readable code next to the message
+func errorCodeResponse(code string, err error) gin.H {
+	return gin.H{"errorCode": code, "errorMessage": err.Error()}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	_idempotencyKeyHeader    = "Idempotency-Key"
+	_idempotencyKeyMaxLength = 255
+)
+
+// responseBodyWriter keeps a copy of the response body so it can be replayed later
+type responseBodyWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseBodyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// idempotencyMiddleware makes a request replayable by its Idempotency-Key header.
+// The first successful response of a key is stored and returned again for every retry with the same body,
+// while a retry with a different body is rejected. Requests without the header are passed through untouched.
+// It must run after authMiddleware since keys are scoped per user.
+func idempotencyMiddleware(store db.Store, duration time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		idempotencyKey := ctx.GetHeader(_idempotencyKeyHeader)
+		if len(idempotencyKey) == 0 {
+			ctx.Next()
+			return
+		}
+
+		if len(idempotencyKey) > _idempotencyKeyMaxLength {
+			err := errors.New("idempotency key is too long")
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+		authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+		// the real path is hashed, a key reused on another resource of the same route is a different request
+		requestHash := hashRequest(ctx.Request.Method, ctx.Request.URL.Path, body)
+
+		_, err = store.CreateIdempotencyKey(ctx, db.CreateIdempotencyKeyParams{
+			Username:       authPayload.Username,
+			IdempotencyKey: idempotencyKey,
+			RequestHash:    requestHash,
+			ExpiresAt:      time.Now().Add(duration),
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				replayIdempotentResponse(ctx, store, authPayload.Username, idempotencyKey, requestHash)
+				return
+			}
+
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		writer := &responseBodyWriter{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = writer
+		ctx.Next()
+
+		if writer.Status() < http.StatusOK || writer.Status() >= http.StatusMultipleChoices {
+			// failed requests are not cached so the client is free to retry with the same key
+			err = store.DeleteIdempotencyKey(ctx, db.DeleteIdempotencyKeyParams{
+				Username:       authPayload.Username,
+				IdempotencyKey: idempotencyKey,
+			})
+			if err != nil {
+				_ = ctx.Error(err)
+			}
+			return
+		}
+
+		_, err = store.UpdateIdempotencyKeyResponse(ctx, db.UpdateIdempotencyKeyResponseParams{
+			Username:       authPayload.Username,
+			IdempotencyKey: idempotencyKey,
+			ResponseStatus: sql.NullInt32{Int32: int32(writer.Status()), Valid: true},
+			ResponseBody:   writer.body.Bytes(),
+		})
+		if err != nil {
+			_ = ctx.Error(err)
+		}
+	}
+}
+
+func replayIdempotentResponse(ctx *gin.Context, store db.Store, username, idempotencyKey, requestHash string) {
+	stored, err := store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username:       username,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if stored.RequestHash != requestHash {
+		err = errors.New("idempotency key was already used with a different request")
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+
+	if !stored.ResponseStatus.Valid {
+		err = errors.New("a request with the same idempotency key is still in progress")
+		ctx.AbortWithStatusJSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+
+	ctx.Data(int(stored.ResponseStatus.Int32), gin.MIMEJSON, stored.ResponseBody)
+	ctx.Abort()
+}
+
+func hashRequest(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte(path))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
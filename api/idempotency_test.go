@@ -0,0 +1,293 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	username := "user"
+	idempotencyKey := "key"
+	idempotentPath := "/idempotent"
+	body := []byte(`{"amount":10}`)
+	requestHash := hashRequest(http.MethodPost, idempotentPath, body)
+	storedBody := []byte(`{"stored":true}`)
+
+	testCases := []struct {
+		Name           string
+		IdempotencyKey string
+		HandlerStatus  int
+		BuildStubs     func(store *mockdb.MockStore)
+		CheckResponse  func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int)
+	}{
+		{
+			Name:           "NoIdempotencyKey",
+			IdempotencyKey: "",
+			HandlerStatus:  http.StatusCreated,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, 1, handlerCalls)
+			},
+		},
+		{
+			Name:           "FirstRequest",
+			IdempotencyKey: idempotencyKey,
+			HandlerStatus:  http.StatusCreated,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, nil)
+				arg := db.UpdateIdempotencyKeyResponseParams{
+					Username:       username,
+					IdempotencyKey: idempotencyKey,
+					ResponseStatus: sql.NullInt32{Int32: http.StatusCreated, Valid: true},
+					ResponseBody:   []byte(`{}`),
+				}
+				store.EXPECT().
+					UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Eq(arg)).
+					Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, 1, handlerCalls)
+			},
+		},
+		{
+			Name:           "FailedRequestReleasesKey",
+			IdempotencyKey: idempotencyKey,
+			HandlerStatus:  http.StatusInternalServerError,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, nil)
+				store.EXPECT().
+					DeleteIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1)
+				store.EXPECT().
+					UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, 1, handlerCalls)
+			},
+		},
+		{
+			Name:           "Replay",
+			IdempotencyKey: idempotencyKey,
+			HandlerStatus:  http.StatusCreated,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{
+						Username:       username,
+						IdempotencyKey: idempotencyKey,
+						RequestHash:    requestHash,
+						ResponseStatus: sql.NullInt32{Int32: http.StatusCreated, Valid: true},
+						ResponseBody:   storedBody,
+					}, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, storedBody, recorder.Body.Bytes())
+				require.Zero(t, handlerCalls)
+			},
+		},
+		{
+			Name:           "MismatchedRequest",
+			IdempotencyKey: idempotencyKey,
+			HandlerStatus:  http.StatusCreated,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{
+						Username:       username,
+						IdempotencyKey: idempotencyKey,
+						RequestHash:    "another",
+						ResponseStatus: sql.NullInt32{Int32: http.StatusCreated, Valid: true},
+						ResponseBody:   storedBody,
+					}, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Zero(t, handlerCalls)
+			},
+		},
+		{
+			Name:           "InProgress",
+			IdempotencyKey: idempotencyKey,
+			HandlerStatus:  http.StatusCreated,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{
+						Username:       username,
+						IdempotencyKey: idempotencyKey,
+						RequestHash:    requestHash,
+					}, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Zero(t, handlerCalls)
+			},
+		},
+		{
+			Name:           "InternalError",
+			IdempotencyKey: idempotencyKey,
+			HandlerStatus:  http.StatusCreated,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.IdempotencyKey{}, sql.ErrConnDone)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, handlerCalls int) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Zero(t, handlerCalls)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+
+			handlerCalls := 0
+			server.router.POST(
+				idempotentPath,
+				authMiddleware(server.tokenMaker, server.revocations, server.store),
+				idempotencyMiddleware(server.store, time.Hour),
+				func(ctx *gin.Context) {
+					handlerCalls++
+					ctx.JSON(tc.HandlerStatus, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, idempotentPath, bytes.NewReader(body))
+			require.NoError(t, err)
+
+			if len(tc.IdempotencyKey) > 0 {
+				request.Header.Set(_idempotencyKeyHeader, tc.IdempotencyKey)
+			}
+			addAuthorization(t, request, server.tokenMaker, _authorizationHeaderBearer, username, roleutil.Depositor, time.Minute)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder, handlerCalls)
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareDifferentResource(t *testing.T) {
+	username := "user"
+	idempotencyKey := "key"
+	body := []byte(`{"amount":10}`)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var stored db.IdempotencyKey
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, arg db.CreateIdempotencyKeyParams) (db.IdempotencyKey, error) {
+				stored = db.IdempotencyKey{
+					Username:       arg.Username,
+					IdempotencyKey: arg.IdempotencyKey,
+					RequestHash:    arg.RequestHash,
+				}
+				return stored, nil
+			}),
+		store.EXPECT().
+			CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.IdempotencyKey{}, sql.ErrNoRows),
+	)
+	store.EXPECT().
+		UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, arg db.UpdateIdempotencyKeyResponseParams) (db.IdempotencyKey, error) {
+			stored.ResponseStatus = arg.ResponseStatus
+			stored.ResponseBody = arg.ResponseBody
+			return stored, nil
+		})
+	store.EXPECT().
+		GetIdempotencyKey(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, _ db.GetIdempotencyKeyParams) (db.IdempotencyKey, error) {
+			return stored, nil
+		})
+
+	server := newTestServer(t, store)
+
+	handlerCalls := 0
+	server.router.POST(
+		"/idempotent/:id",
+		authMiddleware(server.tokenMaker, server.revocations, server.store),
+		idempotencyMiddleware(server.store, time.Hour),
+		func(ctx *gin.Context) {
+			handlerCalls++
+			ctx.JSON(http.StatusCreated, gin.H{})
+		},
+	)
+
+	send := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		require.NoError(t, err)
+
+		request.Header.Set(_idempotencyKeyHeader, idempotencyKey)
+		addAuthorization(t, request, server.tokenMaker, _authorizationHeaderBearer, username, roleutil.Depositor, time.Minute)
+
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusCreated, send("/idempotent/1").Code)
+
+	// the same key and body on another resource must not replay the first response
+	require.Equal(t, http.StatusUnprocessableEntity, send("/idempotent/2").Code)
+	require.Equal(t, 1, handlerCalls)
+}
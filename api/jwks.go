@@ -0,0 +1,19 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thehaung/simplebank/token"
+	"net/http"
+)
+
+// getJwks publishes the public keys verifying our tokens, it's empty when tokens are signed with a shared secret
+func (s *Server) getJwks(ctx *gin.Context) {
+	keySet := token.JSONWebKeySet{Keys: []token.JSONWebKey{}}
+
+	maker, ok := s.tokenMaker.(token.PublicKeyMaker)
+	if ok {
+		keySet = maker.PublicKeys()
+	}
+
+	ctx.JSON(http.StatusOK, keySet)
+}
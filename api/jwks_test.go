@@ -0,0 +1,50 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/token"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetJwksAPI(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"keys":[]}`, recorder.Body.String())
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keyring, err := token.NewAsymmetricKeyring("ed-1", map[string]interface{}{"ed-1": privateKey}, nil)
+	require.NoError(t, err)
+
+	server.tokenMaker, err = token.NewPasetoPublicMaker(keyring, token.ClaimsConfig{})
+	require.NoError(t, err)
+
+	recorder = httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var keySet token.JSONWebKeySet
+	err = json.Unmarshal(recorder.Body.Bytes(), &keySet)
+	require.NoError(t, err)
+	require.Len(t, keySet.Keys, 1)
+	require.Equal(t, "ed-1", keySet.Keys[0].KeyID)
+	require.Equal(t, "OKP", keySet.Keys[0].KeyType)
+	require.Equal(t, "Ed25519", keySet.Keys[0].Curve)
+
+	x, err := base64.RawURLEncoding.DecodeString(keySet.Keys[0].X)
+	require.NoError(t, err)
+	require.Equal(t, []byte(publicKey), x)
+}
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/lockout"
+	"github.com/thehaung/simplebank/util/hashutil"
+	"github.com/thehaung/simplebank/util/randutil"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoginUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	// the password policy decides the length, a lenient one can let shorter passwords in
+	shortPassword := "abc"
+	shortUser := user
+	shortUser.Username = randutil.Owner()
+	hashedShortPassword, err := hashutil.HashPassword(shortPassword)
+	require.NoError(t, err)
+	shortUser.HashedPassword = hashedShortPassword
+
+	testCases := []struct {
+		Name          string
+		Body          gin.H
+		Hasher        hashutil.Hasher
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "OK",
+			Body: gin.H{"username": user.Username, "password": password},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.TotpSecret{}, sql.ErrNoRows)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{Scope: lockout.ScopeUsername, Key: user.Username})).
+					Times(1)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			Name:   "RehashOutdatedHash",
+			Body:   gin.H{"username": user.Username, "password": password},
+			Hasher: hashutil.NewArgon2idHasher(hashutil.Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}),
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					RehashUserPassword(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RehashUserPasswordParams) (int64, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, user.HashedPassword, arg.OldHashedPassword)
+						require.True(t, strings.HasPrefix(arg.NewHashedPassword, "$argon2id$"))
+						require.NoError(t, hashutil.CheckPassword(password, arg.NewHashedPassword))
+						return 1, nil
+					})
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.TotpSecret{}, sql.ErrNoRows)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			Name: "ShortPassword",
+			Body: gin.H{"username": shortUser.Username, "password": shortPassword},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(shortUser.Username)).Times(1).Return(shortUser, nil)
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(shortUser.Username)).Times(1).Return(db.TotpSecret{}, sql.ErrNoRows)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			Name: "MissingPassword",
+			Body: gin.H{"username": user.Username},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			Name: "WrongPassword",
+			Body: gin.H{"username": user.Username, "password": "wrong-password"},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{Failures: 1}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.JSONEq(t, fmt.Sprintf(`{"errorMessage":%q}`, lockout.ErrIncorrectCredentials), recorder.Body.String())
+			},
+		},
+		{
+			Name: "UnknownUser",
+			Body: gin.H{"username": "unknown", "password": password},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq("unknown")).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{Failures: 1}, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.JSONEq(t, fmt.Sprintf(`{"errorMessage":%q}`, lockout.ErrIncorrectCredentials), recorder.Body.String())
+			},
+		},
+		{
+			Name: "ClosedUser",
+			Body: gin.H{"username": user.Username, "password": password},
+			BuildStubs: func(store *mockdb.MockStore) {
+				closed := user
+				closed.Status = db.UserClosed
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(closed, nil)
+				store.EXPECT().RecordLoginFailure(gomock.Any(), gomock.Any()).Times(2).Return(db.LoginThrottle{Failures: 1}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.JSONEq(t, fmt.Sprintf(`{"errorMessage":%q}`, lockout.ErrIncorrectCredentials), recorder.Body.String())
+			},
+		},
+		{
+			Name: "ReachesThreshold",
+			Body: gin.H{"username": user.Username, "password": "wrong-password"},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					RecordLoginFailure(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(func(_ interface{}, arg db.RecordLoginFailureParams) (db.LoginThrottle, error) {
+						if arg.Scope == lockout.ScopeUsername {
+							return db.LoginThrottle{Scope: arg.Scope, Key: arg.Key, Failures: 5}, nil
+						}
+						return db.LoginThrottle{Scope: arg.Scope, Key: arg.Key, Failures: 1}, nil
+					})
+				store.EXPECT().
+					LockLogin(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.LockLoginParams) (db.LoginThrottle, error) {
+						require.Equal(t, lockout.ScopeUsername, arg.Scope)
+						require.Equal(t, user.Username, arg.Key)
+						require.WithinDuration(t, time.Now().Add(time.Minute), arg.LockedUntil.Time, time.Second)
+						return db.LoginThrottle{}, nil
+					})
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "Locked",
+			Body: gin.H{"username": user.Username, "password": password},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Scope: lockout.ScopeUsername, Key: user.Username})).
+					Times(1).
+					Return(db.LoginThrottle{LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			if tc.Hasher != nil {
+				server.hasher = tc.Hasher
+			}
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.Body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.RemoteAddr = "10.0.0.1:12345"
+
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
+
+func TestUnlockUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		Name          string
+		Role          string
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "OK",
+			Role: roleutil.Admin,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{Scope: lockout.ScopeUsername, Key: user.Username})).
+					Times(1).
+					Return(int64(1), nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			Name: "NotLocked",
+			Role: roleutil.Admin,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(int64(0), nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			Name: "NotAdmin",
+			Role: roleutil.Banker,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteLoginThrottle(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/users/%s/lockout", user.Username)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, _authorizationHeaderBearer, "root", tc.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
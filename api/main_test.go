@@ -1,9 +1,12 @@
 package api
 
 import (
+	"database/sql"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"github.com/thehaung/simplebank/config"
+	mockdb "github.com/thehaung/simplebank/db/mock"
 	db "github.com/thehaung/simplebank/db/sqlc"
 	"github.com/thehaung/simplebank/util/randutil"
 	"os"
@@ -11,10 +14,55 @@ import (
 	"time"
 )
 
+const (
+	_testOAuthClientID     = "resource-server"
+	_testOAuthClientSecret = "resource-server-secret"
+	// _testClosedUsername is the user whose account is closed unless a test expects otherwise
+	_testClosedUsername = "closeduser"
+)
+
 func newTestServer(t *testing.T, store db.Store) *Server {
 	conf := &config.Config{
-		TokenSymmetricKey:   randutil.StringWithQuantity(32),
-		AccessTokenDuration: time.Minute,
+		TokenSymmetricKey:     randutil.StringWithQuantity(32),
+		AccessTokenDuration:   time.Minute,
+		RefreshTokenDuration:  time.Hour,
+		MfaTokenDuration:      time.Minute,
+		TotpIssuer:            "simplebank",
+		OAuthClients:          []string{_testOAuthClientID + ":" + _testOAuthClientSecret},
+		LoginMaxAttempts:      5,
+		LoginMaxAttemptsPerIP: 50,
+		LoginLockoutDuration:  time.Minute,
+		LoginMaxLockout:       time.Hour,
+		LoginAttemptWindow:    15 * time.Minute,
+		PasswordMinLength:     6,
+		PasswordCheckBreached: true,
+	}
+
+	// tokens are not revoked, logins are not locked and users are active unless a test expects otherwise
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().
+			GetRevokedToken(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(db.RevokedToken{}, sql.ErrNoRows)
+		mockStore.EXPECT().
+			GetLoginThrottle(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(db.LoginThrottle{}, sql.ErrNoRows)
+		mockStore.EXPECT().
+			RecordLoginFailure(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(db.LoginThrottle{Failures: 1}, nil)
+		mockStore.EXPECT().
+			DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+			AnyTimes()
+		mockStore.EXPECT().
+			GetUserStatus(gomock.Any(), gomock.Not(_testClosedUsername)).
+			AnyTimes().
+			Return(db.UserActive, nil)
+		mockStore.EXPECT().
+			GetUserStatus(gomock.Any(), gomock.Eq(_testClosedUsername)).
+			AnyTimes().
+			Return(db.UserClosed, nil)
 	}
 
 	server, err := NewHttpServer(conf, store)
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/mfa"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/totputil"
+	"net/http"
+	"time"
+)
+
+const _recoveryCodeCount = 10
+
+type enrollTotpResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// enrollTotp generates a new totp secret for the authenticated user.
+// It isn't enforced at login until confirmed, enrolling again before that replaces the secret.
+func (s *Server) enrollTotp(ctx *gin.Context) {
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+	secret, err := totputil.GenerateSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = s.store.UpsertTotpSecret(ctx, db.UpsertTotpSecretParams{
+		Username: authPayload.Username,
+		Secret:   secret,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(db.ErrTotpAlreadyConfirmed))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, enrollTotpResponse{
+		Secret:          secret,
+		ProvisioningURI: totputil.ProvisioningURI(s.cfg.TotpIssuer, authPayload.Username, secret),
+	})
+}
+
+type confirmTotpRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type confirmTotpResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// confirmTotp turns on two-factor authentication once the user proved its authenticator app works,
+// the recovery codes are only returned here
+func (s *Server) confirmTotp(ctx *gin.Context) {
+	var req confirmTotpRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+	secret, err := s.store.GetTotpSecret(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(mfa.ErrNotEnrolled))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if secret.ConfirmedAt.Valid {
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrTotpAlreadyConfirmed))
+		return
+	}
+
+	step, ok := totputil.Validate(secret.Secret, req.Code, time.Now())
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, errorResponse(mfa.ErrInvalidCode))
+		return
+	}
+
+	recoveryCodes, err := totputil.GenerateRecoveryCodes(_recoveryCodeCount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	hashedRecoveryCodes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		hashedRecoveryCodes[i] = totputil.HashRecoveryCode(code)
+	}
+
+	_, err = s.store.ConfirmTotpTx(ctx, db.ConfirmTotpTxParams{
+		Username:            authPayload.Username,
+		Step:                step,
+		HashedRecoveryCodes: hashedRecoveryCodes,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrTotpAlreadyConfirmed) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmTotpResponse{RecoveryCodes: recoveryCodes})
+}
+
+type loginUserMfaRequest struct {
+	MfaToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// loginUserMfa exchanges the mfa token returned by loginUser and a second factor for the access and refresh tokens,
+// the mfa token can only be exchanged once
+func (s *Server) loginUserMfa(ctx *gin.Context) {
+	var req loginUserMfaRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	mfaPayload, err := s.tokenMaker.VerifyToken(req.MfaToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if !mfaPayload.IsMfaPending() {
+		err = errors.New("not an mfa token")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, mfaPayload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if revoked {
+		err = errors.New("mfa token has already been used")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	err = s.loginGuard.Check(ctx, mfaPayload.Username, ctx.ClientIP())
+	if err != nil {
+		loginGuardErrorResponse(ctx, err)
+		return
+	}
+
+	err = mfa.Verify(ctx, s.store, mfaPayload.Username, req.Code, req.RecoveryCode)
+	if err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			s.rejectLogin(ctx, mfaPayload.Username, err)
+			return
+		}
+
+		if errors.Is(err, mfa.ErrNotEnrolled) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = s.revocations.Revoke(ctx, mfaPayload.ID, mfaPayload.Username, mfaPayload.ExpiredAt)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err := s.store.GetUser(ctx, mfaPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.Status != db.UserActive {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errUserClosed))
+		return
+	}
+
+	resp, err := s.createLoginSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, resp)
+}
@@ -0,0 +1,437 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"github.com/thehaung/simplebank/util/totputil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func randomTotpSecret(t *testing.T, username string, confirmed bool) db.TotpSecret {
+	secret, err := totputil.GenerateSecret()
+	require.NoError(t, err)
+
+	totpSecret := db.TotpSecret{
+		Username: username,
+		Secret:   secret,
+	}
+
+	if confirmed {
+		totpSecret.ConfirmedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	return totpSecret
+}
+
+func TestLoginUserWithMfaAPI(t *testing.T) {
+	user, password := randomUser(t)
+	totpSecret := randomTotpSecret(t, user.Username, true)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(totpSecret, nil)
+	store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	var rsp loginUserMfaRequiredResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.True(t, rsp.MfaRequired)
+
+	payload, err := server.tokenMaker.VerifyToken(rsp.MfaToken)
+	require.NoError(t, err)
+	require.True(t, payload.IsMfaPending())
+
+	// the mfa token doesn't give access to anything else
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/sessions", nil)
+	require.NoError(t, err)
+
+	request.Header.Set(_authorizationHeaderKey, _authorizationHeaderBearer+" "+rsp.MfaToken)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestLoginUserMfaAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	totpSecret := randomTotpSecret(t, user.Username, true)
+
+	validCode := func(t *testing.T) string {
+		code, err := totputil.Code(totpSecret.Secret, totputil.Step(time.Now()))
+		require.NoError(t, err)
+		return code
+	}
+
+	testCases := []struct {
+		Name          string
+		Body          func(t *testing.T, mfaToken string) gin.H
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "OK",
+			Body: func(t *testing.T, mfaToken string) gin.H {
+				return gin.H{"mfa_token": mfaToken, "code": validCode(t)}
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(totpSecret, nil)
+				store.EXPECT().
+					UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UseTotpStepParams) (db.TotpSecret, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.InDelta(t, totputil.Step(time.Now()), arg.Step, 1)
+						return totpSecret, nil
+					})
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var rsp loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+			},
+		},
+		{
+			Name: "RecoveryCode",
+			Body: func(t *testing.T, mfaToken string) gin.H {
+				return gin.H{"mfa_token": mfaToken, "recovery_code": "abcde-fghij"}
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(totpSecret, nil)
+				store.EXPECT().
+					UseRecoveryCode(gomock.Any(), gomock.Eq(db.UseRecoveryCodeParams{
+						Username:   user.Username,
+						HashedCode: totputil.HashRecoveryCode("abcde-fghij"),
+					})).
+					Times(1)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			Name: "InvalidCode",
+			Body: func(t *testing.T, mfaToken string) gin.H {
+				code := "000000"
+				if code == validCode(t) {
+					code = "111111"
+				}
+				return gin.H{"mfa_token": mfaToken, "code": code}
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(totpSecret, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "ReplayedCode",
+			Body: func(t *testing.T, mfaToken string) gin.H {
+				return gin.H{"mfa_token": mfaToken, "code": validCode(t)}
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(totpSecret, nil)
+				store.EXPECT().
+					UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TotpSecret{}, sql.ErrNoRows)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "NotAnMfaToken",
+			Body: func(t *testing.T, mfaToken string) gin.H {
+				return gin.H{"mfa_token": "", "code": validCode(t)}
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			Name: "MissingCode",
+			Body: func(t *testing.T, mfaToken string) gin.H {
+				return gin.H{"mfa_token": mfaToken}
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			mfaToken, _, err := server.tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Minute, token.ScopeMfaPending)
+			require.NoError(t, err)
+
+			data, err := json.Marshal(tc.Body(t, mfaToken))
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginUserMfaRejectsAccessToken(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	accessToken, _, err := server.tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Minute)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(gin.H{"mfa_token": accessToken, "code": "123456"})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestEnrollTotpAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		Name          string
+		Scopes        []string
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "OK",
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertTotpSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpsertTotpSecretParams) (db.TotpSecret, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.TotpSecret{Username: arg.Username, Secret: arg.Secret}, nil
+					})
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var rsp enrollTotpResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.Secret)
+				require.Contains(t, rsp.ProvisioningURI, rsp.Secret)
+			},
+		},
+		{
+			Name: "AlreadyConfirmed",
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpsertTotpSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.TotpSecret{}, sql.ErrNoRows)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			Name:   "ScopedToken",
+			Scopes: []string{token.ScopeAccountsRead},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/me/totp", nil)
+			require.NoError(t, err)
+
+			accessToken, _, err := server.tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Minute, tc.Scopes...)
+			require.NoError(t, err)
+
+			request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationHeaderBearer, accessToken))
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
+
+func TestConfirmTotpAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	totpSecret := randomTotpSecret(t, user.Username, false)
+
+	code, err := totputil.Code(totpSecret.Secret, totputil.Step(time.Now()))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		Name          string
+		Code          string
+		Scopes        []string
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "OK",
+			Code: code,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(totpSecret, nil)
+				store.EXPECT().
+					ConfirmTotpTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ConfirmTotpTxParams) (db.TotpSecret, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Len(t, arg.HashedRecoveryCodes, _recoveryCodeCount)
+						return totpSecret, nil
+					})
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp confirmTotpResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.RecoveryCodes, _recoveryCodeCount)
+			},
+		},
+		{
+			Name: "NotEnrolled",
+			Code: code,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.TotpSecret{}, sql.ErrNoRows)
+				store.EXPECT().ConfirmTotpTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			Name: "InvalidCode",
+			Code: "abcdef",
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			Name:   "ScopedToken",
+			Code:   code,
+			Scopes: []string{token.ScopeAccountsRead},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"code": tc.Code})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/me/totp/confirm", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			accessToken, _, err := server.tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Minute, tc.Scopes...)
+			require.NoError(t, err)
+
+			request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationHeaderBearer, accessToken))
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
@@ -1,22 +1,34 @@
 package api
 
 import (
+	"crypto/subtle"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/thehaung/simplebank/config"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/revocation"
 	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/apikeyutil"
 	"net/http"
 	"strings"
+	"time"
 )
 
 const (
 	_authorizationHeaderKey    = "authorization"
 	_authorizationHeaderLength = 2
 	_authorizationHeaderBearer = "bearer"
+	_authorizationHeaderApiKey = "apikey"
 	_authorizationPayloadKey   = "authorization_payload"
+	_authorizationTypeKey      = "authorization_type"
+	_oauthClientKey            = "oauth_client"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// authMiddleware authenticates the request with either a bearer access token or an api key,
+// both end up as a token.Payload in the context
+func authMiddleware(tokenMaker token.Maker, revocations *revocation.List, store db.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(_authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -32,22 +44,242 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 			return
 		}
 
+		var payload *token.Payload
+		var ok bool
+
 		// Index 0 is type 1 is token
 		authorizationType := strings.ToLower(fields[0])
-		if authorizationType != _authorizationHeaderBearer {
+		switch authorizationType {
+		case _authorizationHeaderBearer:
+			payload, ok = verifyAccessToken(ctx, tokenMaker, revocations, fields[1])
+		case _authorizationHeaderApiKey:
+			payload, ok = verifyApiKey(ctx, store, fields[1])
+		default:
 			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
 			return
 		}
 
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		if !ok {
+			return
+		}
+
+		if !verifyActiveUser(ctx, store, payload.Username) {
+			return
+		}
+
+		ctx.Set(_authorizationPayloadKey, payload)
+		ctx.Set(_authorizationTypeKey, authorizationType)
+		ctx.Next()
+	}
+}
+
+func verifyAccessToken(ctx *gin.Context, tokenMaker token.Maker, revocations *revocation.List, accessToken string) (*token.Payload, bool) {
+	payload, err := tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	// the token only proves the password until exchanged at /users/login/mfa
+	if payload.IsMfaPending() {
+		err = errors.New("two-factor authentication is pending")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	if payload.IsRefresh() {
+		err = errors.New("refresh tokens can't be used as access tokens")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	revoked, err := revocations.IsRevoked(ctx, payload)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+
+	if revoked {
+		err = errors.New("token has been revoked")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	return payload, true
+}
+
+// verifyActiveUser refuses the tokens and api keys of closed users,
+// closing a user revokes them already but an access token issued concurrently could slip through
+func verifyActiveUser(ctx *gin.Context, store db.Store, username string) bool {
+	status, err := store.GetUserStatus(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errUserClosed))
+			return false
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if status != db.UserActive {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errUserClosed))
+		return false
+	}
+
+	return true
+}
+
+// verifyApiKey looks the key up by its prefix and turns it into the payload an access token would carry,
+// the role is read from the user so a role change applies to its existing keys
+func verifyApiKey(ctx *gin.Context, store db.Store, key string) (*token.Payload, bool) {
+	prefix, err := apikeyutil.Prefix(key)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	apiKey, err := store.GetApiKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(apikeyutil.ErrInvalidKey))
+			return nil, false
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return nil, false
+	}
+
+	err = apikeyutil.Check(key, apiKey.HashedKey)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	if apiKey.RevokedAt.Valid {
+		err = errors.New("api key has been revoked")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	if apiKey.ExpiresAt.Valid && time.Now().After(apiKey.ExpiresAt.Time) {
+		err = errors.New("api key has expired")
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		return nil, false
+	}
+
+	payload := &token.Payload{
+		ID:        apiKey.ID,
+		Username:  apiKey.Username,
+		Role:      apiKey.Role,
+		Scopes:    apiKey.Scopes,
+		IssuedAt:  apiKey.CreatedAt,
+		NotBefore: apiKey.CreatedAt,
+		ExpiredAt: apiKey.ExpiresAt.Time,
+	}
+
+	return payload, true
+}
+
+// requireRole only lets through the requests whose token carries one of the provided roles,
+// it must be used after authMiddleware
+func requireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+		for _, role := range roles {
+			if authPayload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		err := fmt.Errorf("role %s is not allowed to access this resource", authPayload.Role)
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+	}
+}
+
+// requireScope only lets through the requests whose token grants the provided scope,
+// it must be used after authMiddleware
+func requireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+		if !authPayload.HasScope(scope) {
+			err := fmt.Errorf("token doesn't grant the %s scope", scope)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// requireFullAccess only lets through the access tokens of a login, the scoped tokens and the api keys handed
+// to integrations are refused on the routes that could take over the account or administrate other users.
+// It must be used after authMiddleware.
+func requireFullAccess() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+		// an api key without scopes is unrestricted on the other routes, it still isn't a login
+		if len(authPayload.Scopes) > 0 || ctx.GetString(_authorizationTypeKey) == _authorizationHeaderApiKey {
+			err := errors.New("scoped tokens and api keys can't access this resource")
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// requireVerifiedEmail only lets through the users who verified their email when the config asks for it,
+// it must be used after authMiddleware
+func requireVerifiedEmail(cfg *config.Config, store db.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !cfg.RequireVerifiedEmail {
+			ctx.Next()
+			return
+		}
+
+		authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+		user, err := store.GetUser(ctx, authPayload.Username)
 		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if !user.IsEmailVerified {
+			err = errors.New("email has to be verified first")
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// clientCredentialsMiddleware authenticates the oauth clients with HTTP basic auth,
+// or with the client_id and client_secret form parameters as allowed by RFC 6749
+func clientCredentialsMiddleware(clients map[string]string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		clientID, clientSecret, ok := ctx.Request.BasicAuth()
+		if !ok {
+			clientID = ctx.PostForm("client_id")
+			clientSecret = ctx.PostForm("client_secret")
+		}
+
+		secret, found := clients[clientID]
+		if !found || subtle.ConstantTimeCompare([]byte(secret), []byte(clientSecret)) != 1 {
+			err := errors.New("invalid client credentials")
+			ctx.Header("WWW-Authenticate", `Basic realm="simplebank"`)
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
 			return
 		}
 
-		ctx.Set(_authorizationPayloadKey, payload)
+		ctx.Set(_oauthClientKey, clientID)
 		ctx.Next()
 	}
 }
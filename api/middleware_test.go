@@ -3,8 +3,12 @@ package api
 import (
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
 	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/roleutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -17,9 +21,10 @@ func addAuthorization(
 	tokenMaker token.Maker,
 	authorizationType string,
 	username string,
+	role string,
 	duration time.Duration,
 ) {
-	accessToken, payload, err := tokenMaker.CreateToken(username, duration)
+	accessToken, payload, err := tokenMaker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
@@ -31,12 +36,13 @@ func TestAuthMiddleware(t *testing.T) {
 	testCases := []struct {
 		Name          string
 		SetupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		BuildStubs    func(store *mockdb.MockStore)
 		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			Name: "OK",
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, "user", roleutil.Depositor, time.Minute)
 			},
 			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -45,7 +51,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			Name: "UnsupportedAuthorization",
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "unsupported_type", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "unsupported_type", "user", roleutil.Depositor, time.Minute)
 			},
 			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -62,7 +68,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			Name: "InvalidAuthorizationFormat",
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "", "user", roleutil.Depositor, time.Minute)
 			},
 			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -71,7 +77,31 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			Name: "ExpiredToken",
 			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, "user", -time.Minute)
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, "user", roleutil.Depositor, -time.Minute)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "RefreshToken",
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken("user", roleutil.Depositor, time.Minute, token.ScopeRefresh)
+				require.NoError(t, err)
+
+				request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationHeaderBearer, refreshToken))
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "ClosedUser",
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, "user", roleutil.Depositor, time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserStatus(gomock.Any(), gomock.Eq("user")).Times(1).Return(db.UserClosed, nil)
 			},
 			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -83,12 +113,21 @@ func TestAuthMiddleware(t *testing.T) {
 		tc := testCases[i]
 
 		t.Run(tc.Name, func(t *testing.T) {
-			server := newTestServer(t, nil)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// the stubs come before the defaults of newTestServer so they take precedence
+			store := mockdb.NewMockStore(ctrl)
+			if tc.BuildStubs != nil {
+				tc.BuildStubs(store)
+			}
+
+			server := newTestServer(t, store)
 
 			authPath := "/auth"
 			server.router.GET(
 				authPath,
-				authMiddleware(server.tokenMaker),
+				authMiddleware(server.tokenMaker, server.revocations, server.store),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -104,3 +143,195 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestRequireRoleMiddleware(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Role          string
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "Banker",
+			Role: roleutil.Banker,
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name: "Admin",
+			Role: roleutil.Admin,
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name: "Depositor",
+			Role: roleutil.Depositor,
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revocations, server.store),
+				requireRole(roleutil.Banker, roleutil.Admin),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, _authorizationHeaderBearer, "user", tc.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
+
+func TestRequireScopeMiddleware(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Scopes        []string
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "Unrestricted",
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name:   "GrantedScope",
+			Scopes: []string{token.ScopeAccountsRead, token.ScopeTransfersWrite},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name:   "MissingScope",
+			Scopes: []string{token.ScopeAccountsRead},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revocations, server.store),
+				requireScope(token.ScopeTransfersWrite),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			accessToken, _, err := server.tokenMaker.CreateToken("user", roleutil.Depositor, time.Minute, tc.Scopes...)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationHeaderBearer, accessToken))
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
+
+func TestRequireFullAccessMiddleware(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		SetupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker, store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "LoginToken",
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, store *mockdb.MockStore) {
+				addAuthorization(t, request, tokenMaker, _authorizationHeaderBearer, "user", roleutil.Admin, time.Minute)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name: "ScopedToken",
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, store *mockdb.MockStore) {
+				accessToken, _, err := tokenMaker.CreateToken("user", roleutil.Admin, time.Minute, token.ScopeAccountsRead)
+				require.NoError(t, err)
+				request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationHeaderBearer, accessToken))
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			Name: "UnscopedApiKey",
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, store *mockdb.MockStore) {
+				key, apiKey := randomApiKey(t, "user")
+				apiKey.Scopes = []string{}
+				apiKey.Role = roleutil.Admin
+				store.EXPECT().GetApiKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("ApiKey %s", key))
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revocations, server.store),
+				requireFullAccess(),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			tc.SetupAuth(t, request, server.tokenMaker, store)
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
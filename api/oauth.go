@@ -0,0 +1,226 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// parseOAuthClients parses the "client_id:client_secret" entries of the clients allowed to call the oauth endpoints
+func parseOAuthClients(entries []string) (map[string]string, error) {
+	clients := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		id, secret, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || len(id) == 0 || len(secret) == 0 {
+			return nil, fmt.Errorf("invalid oauth client entry %q: must be client_id:client_secret", entry)
+		}
+
+		if _, ok := clients[id]; ok {
+			return nil, fmt.Errorf("duplicated oauth client id %s", id)
+		}
+
+		clients[id] = secret
+	}
+
+	return clients, nil
+}
+
+type oauthTokenRequest struct {
+	Token         string `form:"token" binding:"required"`
+	TokenTypeHint string `form:"token_type_hint"`
+}
+
+type tokenSessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	IsBlocked bool      `json:"is_blocked"`
+	IsRotated bool      `json:"is_rotated"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type introspectTokenResponse struct {
+	Active   bool                  `json:"active"`
+	Sub      string                `json:"sub,omitempty"`
+	Username string                `json:"username,omitempty"`
+	Role     string                `json:"role,omitempty"`
+	Scope    string                `json:"scope,omitempty"`
+	Iss      string                `json:"iss,omitempty"`
+	Aud      []string              `json:"aud,omitempty"`
+	Exp      int64                 `json:"exp,omitempty"`
+	Iat      int64                 `json:"iat,omitempty"`
+	Nbf      int64                 `json:"nbf,omitempty"`
+	Jti      string                `json:"jti,omitempty"`
+	Session  *tokenSessionResponse `json:"session,omitempty"`
+}
+
+// introspectToken tells a resource server whether a token is active, following RFC 7662.
+// Only access tokens can be active, refresh tokens are reported inactive so a resource server never accepts them.
+// Tokens that can't be verified, were revoked, still wait for the second factor, belong to a closed user
+// or whose session is blocked are reported inactive as well, without any other detail.
+func (s *Server) introspectToken(ctx *gin.Context) {
+	var req oauthTokenRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	inactive := introspectTokenResponse{Active: false}
+
+	payload, err := s.tokenMaker.VerifyToken(req.Token)
+	if err != nil {
+		ctx.JSON(http.StatusOK, inactive)
+		return
+	}
+
+	// the token only proves the password until the second factor is checked
+	if payload.IsMfaPending() || payload.IsRefresh() {
+		ctx.JSON(http.StatusOK, inactive)
+		return
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, payload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if revoked {
+		ctx.JSON(http.StatusOK, inactive)
+		return
+	}
+
+	userStatus, err := s.store.GetUserStatus(ctx, payload.Username)
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err == sql.ErrNoRows || userStatus != db.UserActive {
+		ctx.JSON(http.StatusOK, inactive)
+		return
+	}
+
+	session, isRefreshToken, found, err := s.findTokenSession(ctx, payload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the refresh tokens issued before the refresh marker are only recognized by their session
+	if found && isRefreshToken {
+		ctx.JSON(http.StatusOK, inactive)
+		return
+	}
+
+	rsp := introspectTokenResponse{
+		Active:   true,
+		Sub:      payload.Username,
+		Username: payload.Username,
+		Role:     payload.Role,
+		Scope:    strings.Join(payload.Scopes, " "),
+		Iss:      payload.Issuer,
+		Aud:      payload.Audience,
+		Exp:      payload.ExpiredAt.Unix(),
+		Iat:      payload.IssuedAt.Unix(),
+		Nbf:      payload.NotBefore.Unix(),
+		Jti:      payload.ID.String(),
+	}
+
+	if found {
+		rsp.Session = &tokenSessionResponse{
+			ID:        session.ID,
+			IsBlocked: session.IsBlocked,
+			IsRotated: session.ReplacedBy.Valid,
+			ExpiresAt: session.ExpiresAt,
+		}
+
+		// an access token outlives the rotation of its session but not its blocking
+		if session.IsBlocked {
+			ctx.JSON(http.StatusOK, inactive)
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// revokeOAuthToken revokes a token following RFC 7009.
+// The session the token belongs to is blocked, which revokes both its refresh token and its access token.
+// Invalid tokens are ignored since there is nothing left to revoke.
+func (s *Server) revokeOAuthToken(ctx *gin.Context) {
+	var req oauthTokenRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := s.tokenMaker.VerifyToken(req.Token)
+	if err != nil {
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	session, _, found, err := s.findTokenSession(ctx, payload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !found {
+		err = s.revocations.Revoke(ctx, payload.ID, payload.Username, payload.ExpiredAt)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	blocked, err := s.store.BlockSession(ctx, db.BlockSessionParams{
+		ID:       session.ID,
+		Username: session.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = s.revocations.RevokeSessions(ctx, []db.Session{blocked})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// findTokenSession finds the session a token was issued with, refresh tokens share their id with the session
+// while access tokens are referenced by it
+func (s *Server) findTokenSession(ctx *gin.Context, payload *token.Payload) (session db.Session, isRefreshToken bool, found bool, err error) {
+	session, err = s.store.GetSession(ctx, payload.ID)
+	if err == nil {
+		return session, true, true, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return session, false, false, err
+	}
+
+	session, err = s.store.GetSessionByAccessToken(ctx, uuid.NullUUID{UUID: payload.ID, Valid: true})
+	if err == nil {
+		return session, false, true, nil
+	}
+
+	if err != sql.ErrNoRows {
+		return session, false, false, err
+	}
+
+	return session, false, false, nil
+}
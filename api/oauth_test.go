@@ -0,0 +1,315 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newOAuthRequest(t *testing.T, path string, tokenValue string, clientSecret string) *http.Request {
+	form := url.Values{}
+	form.Set("token", tokenValue)
+
+	request, err := http.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.SetBasicAuth(_testOAuthClientID, clientSecret)
+
+	return request
+}
+
+func TestIntrospectTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		Name          string
+		Token         func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload)
+		ClientSecret  string
+		BuildStubs    func(store *mockdb.MockStore, payload *token.Payload)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder, payload *token.Payload)
+	}{
+		{
+			Name: "ActiveAccessToken",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				accessToken, payload, err := tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Minute, token.ScopeAccountsRead)
+				require.NoError(t, err)
+				return accessToken, payload
+			},
+			ClientSecret: _testOAuthClientSecret,
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.Session{}, sql.ErrNoRows)
+				store.EXPECT().
+					GetSessionByAccessToken(gomock.Any(), gomock.Eq(uuid.NullUUID{UUID: payload.ID, Valid: true})).
+					Times(1).
+					Return(db.Session{ID: uuid.New(), Username: user.Username}, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, payload *token.Payload) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp introspectTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.Active)
+				require.Equal(t, user.Username, rsp.Sub)
+				require.Equal(t, payload.ID.String(), rsp.Jti)
+				require.Equal(t, token.ScopeAccountsRead, rsp.Scope)
+				require.Equal(t, payload.ExpiredAt.Unix(), rsp.Exp)
+				require.NotNil(t, rsp.Session)
+				require.False(t, rsp.Session.IsBlocked)
+			},
+		},
+		{
+			Name: "RefreshToken",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				refreshToken, payload, err := tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Hour, token.ScopeRefresh)
+				require.NoError(t, err)
+				return refreshToken, payload
+			},
+			ClientSecret: _testOAuthClientSecret,
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, payload *token.Payload) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"active":false}`, recorder.Body.String())
+			},
+		},
+		{
+			Name: "LegacyRefreshToken",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				refreshToken, payload, err := tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Hour)
+				require.NoError(t, err)
+				return refreshToken, payload
+			},
+			ClientSecret: _testOAuthClientSecret,
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				// a refresh token that wasn't rotated yet is still refused
+				session := db.Session{
+					ID:        payload.ID,
+					Username:  user.Username,
+					ExpiresAt: payload.ExpiredAt,
+				}
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(session, nil)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, payload *token.Payload) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"active":false}`, recorder.Body.String())
+			},
+		},
+		{
+			Name: "MfaPendingToken",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				mfaToken, payload, err := tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Minute, token.ScopeMfaPending)
+				require.NoError(t, err)
+				return mfaToken, payload
+			},
+			ClientSecret: _testOAuthClientSecret,
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, payload *token.Payload) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"active":false}`, recorder.Body.String())
+			},
+		},
+		{
+			Name: "ClosedUser",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				accessToken, payload, err := tokenMaker.CreateToken(_testClosedUsername, roleutil.Depositor, time.Minute)
+				require.NoError(t, err)
+				return accessToken, payload
+			},
+			ClientSecret: _testOAuthClientSecret,
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, payload *token.Payload) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"active":false}`, recorder.Body.String())
+			},
+		},
+		{
+			Name: "InvalidToken",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return "invalid", &token.Payload{}
+			},
+			ClientSecret: _testOAuthClientSecret,
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, payload *token.Payload) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"active":false}`, recorder.Body.String())
+			},
+		},
+		{
+			Name: "InvalidClient",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				accessToken, payload, err := tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Minute)
+				require.NoError(t, err)
+				return accessToken, payload
+			},
+			ClientSecret: "wrong-secret",
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, payload *token.Payload) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			tokenValue, payload := tc.Token(t, server.tokenMaker)
+			tc.BuildStubs(store, payload)
+
+			recorder := httptest.NewRecorder()
+			request := newOAuthRequest(t, "/oauth/introspect", tokenValue, tc.ClientSecret)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder, payload)
+		})
+	}
+}
+
+func TestRevokeOAuthTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		Name          string
+		Token         func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload)
+		ClientSecret  string
+		BuildStubs    func(store *mockdb.MockStore, payload *token.Payload)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "RefreshToken",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				refreshToken, payload, err := tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Hour)
+				require.NoError(t, err)
+				return refreshToken, payload
+			},
+			ClientSecret: _testOAuthClientSecret,
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				session := db.Session{
+					ID:                   payload.ID,
+					Username:             user.Username,
+					AccessTokenID:        uuid.NullUUID{UUID: uuid.New(), Valid: true},
+					AccessTokenExpiresAt: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+				}
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(session, nil)
+
+				blocked := session
+				blocked.IsBlocked = true
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Eq(db.BlockSessionParams{ID: session.ID, Username: user.Username})).
+					Times(1).
+					Return(blocked, nil)
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Eq(db.RevokeTokenParams{
+						ID:        session.AccessTokenID.UUID,
+						Username:  user.Username,
+						ExpiresAt: session.AccessTokenExpiresAt.Time,
+					})).
+					Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name: "TokenWithoutSession",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				accessToken, payload, err := tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Minute)
+				require.NoError(t, err)
+				return accessToken, payload
+			},
+			ClientSecret: _testOAuthClientSecret,
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.Session{}, sql.ErrNoRows)
+				store.EXPECT().GetSessionByAccessToken(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrNoRows)
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RevokeTokenParams) error {
+						require.Equal(t, payload.ID, arg.ID)
+						return nil
+					})
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name: "InvalidToken",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				return "invalid", &token.Payload{}
+			},
+			ClientSecret: _testOAuthClientSecret,
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name: "InvalidClient",
+			Token: func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+				refreshToken, payload, err := tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Hour)
+				require.NoError(t, err)
+				return refreshToken, payload
+			},
+			ClientSecret: "wrong-secret",
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().BlockSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			tokenValue, payload := tc.Token(t, server.tokenMaker)
+			tc.BuildStubs(store, payload)
+
+			recorder := httptest.NewRecorder()
+			request := newOAuthRequest(t, "/oauth/revoke", tokenValue, tc.ClientSecret)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
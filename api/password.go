@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/notify"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/hashutil"
+	"github.com/thehaung/simplebank/util/secretutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const _errorCodeWeakPassword = "weak_password"
+
+var (
+	errIncorrectPassword = errors.New("current password is incorrect")
+	errWeakPassword      = errors.New("password doesn't satisfy the password policy")
+)
+
+// isAllowedPassword checks the password against the password policy and responds with the broken rules,
+// the username and email of the user must not be part of it
+func (s *Server) isAllowedPassword(ctx *gin.Context, field string, password string, user db.User) bool {
+	violations := s.passwords.Validate(password, user.Username, user.Email)
+	if len(violations) == 0 {
+		return true
+	}
+
+	fieldViolations := make([]fieldViolation, len(violations))
+	for i, violation := range violations {
+		fieldViolations[i] = fieldViolation{
+			Field:       field,
+			Rule:        violation.Rule,
+			Description: violation.Description,
+		}
+	}
+
+	ctx.JSON(http.StatusBadRequest, fieldViolationsResponse(_errorCodeWeakPassword, errWeakPassword, fieldViolations))
+	return false
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// changePassword sets a new password for the authenticated user and logs out its other sessions.
+// Wrong current passwords count towards the login lockout, otherwise a stolen access token could guess it.
+// It requires a full access token, the integrations must not be able to take over the account.
+func (s *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+	err := s.loginGuard.Check(ctx, authPayload.Username, ctx.ClientIP())
+	if err != nil {
+		loginGuardErrorResponse(ctx, err)
+		return
+	}
+
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = hashutil.CheckPassword(req.CurrentPassword, user.HashedPassword)
+	if err != nil {
+		s.rejectLogin(ctx, user.Username, errIncorrectPassword)
+		return
+	}
+
+	if !s.isAllowedPassword(ctx, "new_password", req.NewPassword, user) {
+		return
+	}
+
+	hashedPassword, err := s.hasher.Hash(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := s.store.ChangePasswordTx(ctx, db.ChangePasswordTxParams{
+		Username:             user.Username,
+		HashedPassword:       hashedPassword,
+		CurrentAccessTokenID: authPayload.ID,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = s.revocations.RevokeSessions(ctx, result.BlockedSessions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
+
+// rehashPassword hashes the password again when its stored hash was made with outdated parameters,
+// it's only possible at login since it needs the plain password
+func (s *Server) rehashPassword(ctx context.Context, user db.User, password string) error {
+	if !s.hasher.NeedsRehash(user.HashedPassword) {
+		return nil
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	// the password may have changed since it was checked, the new one must not be overwritten
+	_, err = s.store.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+		NewHashedPassword: hashedPassword,
+		Username:          user.Username,
+		OldHashedPassword: user.HashedPassword,
+	})
+	return err
+}
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// requestPasswordReset sends a single-use reset link to the user owning the email.
+// It answers 202 even when no user has the email so accounts can't be enumerated.
+func (s *Server) requestPasswordReset(ctx *gin.Context) {
+	var req requestPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := s.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.Status(http.StatusAccepted)
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	resetToken, err := secretutil.Generate()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	expiresAt := time.Now().Add(s.cfg.PasswordResetDuration)
+	_, err = s.store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		Username:    user.Username,
+		HashedToken: secretutil.Hash(resetToken),
+		ExpiresAt:   expiresAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	link := notify.Link(s.cfg.PasswordResetURL, url.Values{"token": {resetToken}})
+	err = s.notifier.Notify(ctx, notify.PasswordResetMessage(user.Username, user.FullName, user.Email, link, expiresAt))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+type confirmPasswordResetRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// confirmPasswordReset sets the new password of the user the reset token was sent to and logs out all its sessions,
+// the user proved it owns the account so its login lockout is lifted as well
+func (s *Server) confirmPasswordReset(ctx *gin.Context) {
+	var req confirmPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedToken := secretutil.Hash(req.Token)
+
+	// the user is needed first to make sure the new password doesn't contain its username or email
+	user, err := s.store.GetUserByPasswordResetToken(ctx, hashedToken)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(db.ErrInvalidPasswordResetToken))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !s.isAllowedPassword(ctx, "new_password", req.NewPassword, user) {
+		return
+	}
+
+	hashedPassword, err := s.hasher.Hash(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := s.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		HashedToken:    hashedToken,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidPasswordResetToken) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = s.revocations.RevokeSessions(ctx, result.BlockedSessions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = s.loginGuard.RecordSuccess(ctx, result.User.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
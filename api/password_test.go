@@ -0,0 +1,368 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/notify"
+	"github.com/thehaung/simplebank/passwordpolicy"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/hashutil"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"github.com/thehaung/simplebank/util/secretutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type recordingNotifier struct {
+	messages []notify.Message
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, msg notify.Message) error {
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func blockedSession(username string) db.Session {
+	return db.Session{
+		ID:                   uuid.New(),
+		Username:             username,
+		AccessTokenID:        uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		AccessTokenExpiresAt: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+	}
+}
+
+func requireFieldViolation(t *testing.T, recorder *httptest.ResponseRecorder, field string, rule string) {
+	var resp struct {
+		ErrorCode       string           `json:"errorCode"`
+		FieldViolations []fieldViolation `json:"fieldViolations"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, _errorCodeWeakPassword, resp.ErrorCode)
+
+	for _, violation := range resp.FieldViolations {
+		if violation.Field == field && violation.Rule == rule {
+			return
+		}
+	}
+	require.Failf(t, "missing field violation", "%s %s in %v", field, rule, resp.FieldViolations)
+}
+
+func TestChangePasswordAPI(t *testing.T) {
+	user, password := randomUser(t)
+	otherSession := blockedSession(user.Username)
+
+	testCases := []struct {
+		Name          string
+		Body          gin.H
+		Scopes        []string
+		BuildStubs    func(store *mockdb.MockStore, payload *token.Payload)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "OK",
+			Body: gin.H{"current_password": password, "new_password": "new-secret"},
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					ChangePasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ChangePasswordTxParams) (db.PasswordTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, payload.ID, arg.CurrentAccessTokenID)
+						require.NoError(t, hashutil.CheckPassword("new-secret", arg.HashedPassword))
+						return db.PasswordTxResult{User: user, BlockedSessions: []db.Session{otherSession}}, nil
+					})
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RevokeTokenParams) error {
+						require.Equal(t, otherSession.AccessTokenID.UUID, arg.ID)
+						return nil
+					})
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "hashed_password")
+			},
+		},
+		{
+			Name: "WrongCurrentPassword",
+			Body: gin.H{"current_password": "wrong-password", "new_password": "new-secret"},
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ChangePasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name:   "ScopedToken",
+			Body:   gin.H{"current_password": password, "new_password": "new-secret"},
+			Scopes: []string{token.ScopeAccountsRead},
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ChangePasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			Name: "NewPasswordTooShort",
+			Body: gin.H{"current_password": password, "new_password": "abc"},
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ChangePasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireFieldViolation(t, recorder, "new_password", passwordpolicy.RuleMinLength)
+			},
+		},
+		{
+			Name: "NewPasswordContainsUsername",
+			Body: gin.H{"current_password": password, "new_password": "my-" + user.Username + "-1"},
+			BuildStubs: func(store *mockdb.MockStore, payload *token.Payload) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ChangePasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireFieldViolation(t, recorder, "new_password", passwordpolicy.RuleUserInfo)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			accessToken, payload, err := server.tokenMaker.CreateToken(user.Username, roleutil.Depositor, time.Minute, tc.Scopes...)
+			require.NoError(t, err)
+			tc.BuildStubs(store, payload)
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.Body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPut, "/users/me/password", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationHeaderBearer, accessToken))
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
+
+func TestRequestPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		Name          string
+		Body          gin.H
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder, notifier *recordingNotifier)
+	}{
+		{
+			Name: "OK",
+			Body: gin.H{"email": user.Email},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreatePasswordResetTokenParams) (db.PasswordResetToken, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(30*time.Minute), arg.ExpiresAt, time.Second)
+						return db.PasswordResetToken{Username: arg.Username, HashedToken: arg.HashedToken}, nil
+					})
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Len(t, notifier.messages, 1)
+				require.Equal(t, user.Email, notifier.messages[0].Email)
+				require.Contains(t, notifier.messages[0].Body, "https://bank.example/reset?token=")
+			},
+		},
+		{
+			Name: "UnknownEmail",
+			Body: gin.H{"email": "unknown@example.com"},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Empty(t, notifier.messages)
+			},
+		},
+		{
+			Name: "InvalidEmail",
+			Body: gin.H{"email": "not-an-email"},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			server.cfg.PasswordResetDuration = 30 * time.Minute
+			server.cfg.PasswordResetURL = "https://bank.example/reset"
+			notifier := &recordingNotifier{}
+			server.notifier = notifier
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.Body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/password-reset", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder, notifier)
+		})
+	}
+}
+
+func TestConfirmPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	resetToken, err := secretutil.Generate()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		Name          string
+		Body          gin.H
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			Name: "OK",
+			Body: gin.H{"token": resetToken, "new_password": "new-secret"},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByPasswordResetToken(gomock.Any(), gomock.Eq(secretutil.Hash(resetToken))).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ResetPasswordTxParams) (db.PasswordTxResult, error) {
+						require.Equal(t, secretutil.Hash(resetToken), arg.HashedToken)
+						require.NoError(t, hashutil.CheckPassword("new-secret", arg.HashedPassword))
+						return db.PasswordTxResult{User: user, BlockedSessions: []db.Session{blockedSession(user.Username)}}, nil
+					})
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			Name: "InvalidToken",
+			Body: gin.H{"token": resetToken, "new_password": "new-secret"},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByPasswordResetToken(gomock.Any(), gomock.Eq(secretutil.Hash(resetToken))).
+					Times(1).
+					Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "TokenUsedConcurrently",
+			Body: gin.H{"token": resetToken, "new_password": "new-secret"},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByPasswordResetToken(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PasswordTxResult{}, db.ErrInvalidPasswordResetToken)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "BreachedPassword",
+			Body: gin.H{"token": resetToken, "new_password": "password123"},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUserByPasswordResetToken(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireFieldViolation(t, recorder, "new_password", passwordpolicy.RuleBreached)
+			},
+		},
+		{
+			Name: "MissingToken",
+			Body: gin.H{"new_password": "new-secret"},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.Body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/password-reset/confirm", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder)
+		})
+	}
+}
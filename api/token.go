@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
 	"net/http"
 	"time"
 )
@@ -13,10 +16,16 @@ type renewAccessTokenRequest struct {
 }
 
 type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
+// renewAccessToken rotates the refresh token on every call.
+// Presenting a refresh token that was already rotated means it leaked, so the whole family of sessions
+// descending from the same login is blocked, including the one held by the legitimate client.
 func (s *Server) renewAccessToken(ctx *gin.Context) {
 	var req renewAccessTokenRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -41,6 +50,11 @@ func (s *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
+	if session.ReplacedBy.Valid {
+		s.blockSessionFamily(ctx, session.FamilyID)
+		return
+	}
+
 	if session.IsBlocked {
 		err = errors.New("blocked session")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
@@ -65,16 +79,84 @@ func (s *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, accessPayload, err := s.tokenMaker.CreateToken(refreshPayload.Username, s.cfg.AccessTokenDuration)
+	// the role is read again so a role change applies to the sessions that keep renewing
+	user, err := s.store.GetUser(ctx, session.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errUserClosed))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.Status != db.UserActive {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errUserClosed))
+		return
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, s.cfg.AccessTokenDuration, refreshPayload.AccessScopes()...)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	refreshToken, newRefreshPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, s.cfg.RefreshTokenDuration, append(refreshPayload.AccessScopes(), token.ScopeRefresh)...)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	newSession, err := s.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		OldSessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:                   newRefreshPayload.ID,
+			Username:             session.Username,
+			RefreshToken:         refreshToken,
+			UserAgent:            ctx.Request.UserAgent(),
+			ClientIp:             ctx.ClientIP(),
+			IsBlocked:            false,
+			ExpiresAt:            newRefreshPayload.ExpiredAt,
+			AccessTokenID:        uuid.NullUUID{UUID: accessPayload.ID, Valid: true},
+			AccessTokenExpiresAt: sql.NullTime{Time: accessPayload.ExpiredAt, Valid: true},
+		},
+	})
+	if err != nil {
+		// another renewal with the same refresh token won the race
+		if errors.Is(err, db.ErrSessionReused) {
+			s.blockSessionFamily(ctx, session.FamilyID)
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
 	resp := renewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		SessionID:             newSession.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newRefreshPayload.ExpiredAt,
 	}
 
 	ctx.JSON(http.StatusAccepted, resp)
 }
+
+// blockSessionFamily answers a refresh token reuse by blocking every session of its family
+func (s *Server) blockSessionFamily(ctx *gin.Context, familyID uuid.UUID) {
+	sessions, err := s.store.BlockSessionFamily(ctx, familyID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = s.revocations.RevokeSessions(ctx, sessions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusUnauthorized, errorResponse(db.ErrSessionReused))
+}
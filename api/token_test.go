@@ -0,0 +1,212 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	familyID := uuid.New()
+
+	testCases := []struct {
+		Name          string
+		BuildStubs    func(store *mockdb.MockStore, session db.Session)
+		CheckResponse func(t *testing.T, recorder *httptest.ResponseRecorder, session db.Session, tokenMaker token.Maker)
+	}{
+		{
+			Name: "OK",
+			BuildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(session.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RotateSessionTxParams) (db.Session, error) {
+						require.Equal(t, session.ID, arg.OldSessionID)
+						require.NotEqual(t, session.RefreshToken, arg.NewSession.RefreshToken)
+						return db.Session{ID: arg.NewSession.ID, FamilyID: familyID}, nil
+					})
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, session db.Session, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+
+				var resp renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+				require.NotEmpty(t, resp.AccessToken)
+				require.NotEmpty(t, resp.RefreshToken)
+				require.NotEqual(t, session.RefreshToken, resp.RefreshToken)
+				require.NotEqual(t, session.ID, resp.SessionID)
+
+				accessPayload, err := tokenMaker.VerifyToken(resp.AccessToken)
+				require.NoError(t, err)
+				require.False(t, accessPayload.IsRefresh())
+				require.Empty(t, accessPayload.Scopes)
+
+				refreshPayload, err := tokenMaker.VerifyToken(resp.RefreshToken)
+				require.NoError(t, err)
+				require.True(t, refreshPayload.IsRefresh())
+			},
+		},
+		{
+			Name: "ReusedRefreshToken",
+			BuildStubs: func(store *mockdb.MockStore, session db.Session) {
+				session.ReplacedBy = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+				blocked := db.Session{
+					ID:                   uuid.New(),
+					Username:             session.Username,
+					FamilyID:             familyID,
+					AccessTokenID:        uuid.NullUUID{UUID: uuid.New(), Valid: true},
+					AccessTokenExpiresAt: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+				}
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().
+					BlockSessionFamily(gomock.Any(), gomock.Eq(familyID)).
+					Times(1).
+					Return([]db.Session{session, blocked}, nil)
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Eq(db.RevokeTokenParams{
+						ID:        session.ID,
+						Username:  session.Username,
+						ExpiresAt: session.ExpiresAt,
+					})).
+					Times(1)
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Eq(db.RevokeTokenParams{
+						ID:        blocked.AccessTokenID.UUID,
+						Username:  blocked.Username,
+						ExpiresAt: blocked.AccessTokenExpiresAt.Time,
+					})).
+					Times(1)
+				store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, session db.Session, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "ConcurrentRotation",
+			BuildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(session.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, db.ErrSessionReused)
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Eq(familyID)).Times(1).Return([]db.Session{session}, nil)
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Eq(db.RevokeTokenParams{
+						ID:        session.ID,
+						Username:  session.Username,
+						ExpiresAt: session.ExpiresAt,
+					})).
+					Times(1)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, session db.Session, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "RoleChanged",
+			BuildStubs: func(store *mockdb.MockStore, session db.Session) {
+				demoted := user
+				demoted.Role = roleutil.Banker
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(session.Username)).Times(1).Return(demoted, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RotateSessionTxParams) (db.Session, error) {
+						return db.Session{ID: arg.NewSession.ID, FamilyID: familyID}, nil
+					})
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, session db.Session, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				var resp renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+				require.NoError(t, err)
+
+				payload, err := tokenMaker.VerifyToken(resp.AccessToken)
+				require.NoError(t, err)
+				require.Equal(t, roleutil.Banker, payload.Role)
+			},
+		},
+		{
+			Name: "ClosedUser",
+			BuildStubs: func(store *mockdb.MockStore, session db.Session) {
+				closed := user
+				closed.Status = db.UserClosed
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(session.Username)).Times(1).Return(closed, nil)
+				store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, session db.Session, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			Name: "BlockedSession",
+			BuildStubs: func(store *mockdb.MockStore, session db.Session) {
+				session.IsBlocked = true
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, session db.Session, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			session := newTestSession(t, server.tokenMaker, user.Username, familyID)
+			tc.BuildStubs(store, session)
+
+			data, err := json.Marshal(gin.H{"refresh_token": session.RefreshToken})
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/token/renew_access", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.CheckResponse(t, recorder, session, server.tokenMaker)
+		})
+	}
+}
+
+func newTestSession(t *testing.T, tokenMaker token.Maker, username string, familyID uuid.UUID) db.Session {
+	refreshToken, payload, err := tokenMaker.CreateToken(username, roleutil.Depositor, time.Hour, token.ScopeRefresh)
+	require.NoError(t, err)
+
+	return db.Session{
+		ID:           payload.ID,
+		Username:     username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    payload.ExpiredAt,
+		FamilyID:     familyID,
+	}
+}
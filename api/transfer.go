@@ -7,14 +7,22 @@ import (
 	"github.com/gin-gonic/gin"
 	db "github.com/thehaung/simplebank/db/sqlc"
 	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/roleutil"
 	"net/http"
 )
 
+const (
+	_errorCodeInsufficientFunds     = "insufficient_funds"
+	_errorCodeTransferNotReversible = "transfer_not_reversible"
+	_errorCodeAccountClosed         = "account_closed"
+)
+
 type transferRequest struct {
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
 	Amount        int64  `json:"amount" binding:"required,gt=0"`
 	Currency      string `json:"currency" binding:"required,currency"`
+	QuoteID       string `json:"quote_id" binding:"omitempty,uuid"`
 }
 
 func (s *Server) createTransfer(ctx *gin.Context) {
@@ -23,6 +31,12 @@ func (s *Server) createTransfer(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+
+	if len(req.QuoteID) > 0 {
+		s.createFxTransfer(ctx, req)
+		return
+	}
+
 	fromAccount, valid := s.isValidAccount(ctx, req.FromAccountID, req.Currency)
 	if !valid {
 		return
@@ -48,7 +62,14 @@ func (s *Server) createTransfer(ctx *gin.Context) {
 
 	account, err := s.store.TransferTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		switch {
+		case errors.Is(err, db.ErrInsufficientFunds):
+			ctx.JSON(http.StatusUnprocessableEntity, errorCodeResponse(_errorCodeInsufficientFunds, err))
+		case errors.Is(err, db.ErrAccountClosed):
+			ctx.JSON(http.StatusUnprocessableEntity, errorCodeResponse(_errorCodeAccountClosed, err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
 		return
 	}
 
@@ -75,3 +96,77 @@ func (s *Server) isValidAccount(ctx *gin.Context, accountID int64, currency stri
 
 	return account, true
 }
+
+type reverseTransferUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type reverseTransferRequest struct {
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+func (s *Server) reverseTransfer(ctx *gin.Context) {
+	var uri reverseTransferUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the body is optional, an empty one reverses the whole remaining amount
+	var req reverseTransferRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	transfer, err := s.store.GetTransfer(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	toAccount, err := s.store.GetAccount(ctx, transfer.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+	if toAccount.Owner != authPayload.Username && authPayload.Role != roleutil.Admin {
+		err = errors.New("only the recipient or an admin can reverse a transfer")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	arg := db.ReverseTransferTxParams{
+		TransferID: transfer.ID,
+		Amount:     req.Amount,
+	}
+
+	result, err := s.store.ReverseTransferTx(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrInsufficientFunds):
+			ctx.JSON(http.StatusUnprocessableEntity, errorCodeResponse(_errorCodeInsufficientFunds, err))
+		case errors.Is(err, db.ErrAccountClosed):
+			ctx.JSON(http.StatusUnprocessableEntity, errorCodeResponse(_errorCodeAccountClosed, err))
+		case errors.Is(err, db.ErrTransferAlreadyReversed),
+			errors.Is(err, db.ErrReversalExceedsRemaining),
+			errors.Is(err, db.ErrReversalOfReversal),
+			errors.Is(err, db.ErrReversalOfFxTransfer):
+			ctx.JSON(http.StatusUnprocessableEntity, errorCodeResponse(_errorCodeTransferNotReversible, err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, result)
+}
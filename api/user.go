@@ -2,18 +2,27 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/lockout"
+	"github.com/thehaung/simplebank/mfa"
+	"github.com/thehaung/simplebank/token"
 	"github.com/thehaung/simplebank/util/hashutil"
+	"github.com/thehaung/simplebank/util/secretutil"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 )
 
+var errUserClosed = errors.New("user is closed")
+
 type createUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 	FullName string `json:"full_name" binding:"required,gt=0"`
 	Email    string `json:"email" binding:"required,email"`
 }
@@ -22,6 +31,9 @@ type userResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	Status            string    `json:"status"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -33,20 +45,37 @@ func (s *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	hashedPassword, err := hashutil.HashPassword(req.Password)
+	if !s.isAllowedPassword(ctx, "password", req.Password, db.User{Username: req.Username, Email: req.Email}) {
+		return
+	}
+
+	hashedPassword, err := s.hasher.Hash(req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	arg := db.CreateUserParams{
-		Username:       req.Username,
-		HashedPassword: hashedPassword,
-		FullName:       req.FullName,
-		Email:          req.Email,
+	secretCode, err := secretutil.Generate()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
-	user, err := s.store.CreateUser(ctx, arg)
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			HashedPassword: hashedPassword,
+			FullName:       req.FullName,
+			Email:          req.Email,
+		},
+		HashedSecretCode:     secretutil.Hash(secretCode),
+		VerifyEmailExpiresAt: time.Now().Add(s.cfg.VerifyEmailDuration),
+		AfterCreate: func(user db.User, verifyEmail db.VerifyEmail) error {
+			return s.sendVerifyEmail(ctx, user, verifyEmail, secretCode)
+		},
+	}
+
+	result, err := s.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		pgErr, ok := err.(*pq.Error)
 		if ok {
@@ -60,7 +89,7 @@ func (s *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	resp := newUserResponse(user)
+	resp := newUserResponse(result.User)
 
 	ctx.JSON(http.StatusCreated, resp)
 }
@@ -70,6 +99,9 @@ func newUserResponse(user db.User) userResponse {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		Role:              user.Role,
+		IsEmailVerified:   user.IsEmailVerified,
+		Status:            user.Status,
 		CreatedAt:         user.CreatedAt,
 		PasswordChangedAt: user.PasswordChangedAt,
 	}
@@ -77,7 +109,7 @@ func newUserResponse(user db.User) userResponse {
 
 type loginUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
 }
 
 type loginUserResponse struct {
@@ -89,6 +121,14 @@ type loginUserResponse struct {
 	User                  userResponse `json:"user"`
 }
 
+// loginUserMfaRequiredResponse is returned instead of the tokens when the user enabled two-factor authentication,
+// the mfa token has to be exchanged at /users/login/mfa together with a code
+type loginUserMfaRequiredResponse struct {
+	MfaRequired       bool      `json:"mfa_required"`
+	MfaToken          string    `json:"mfa_token"`
+	MfaTokenExpiresAt time.Time `json:"mfa_token_expires_at"`
+}
+
 func (s *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -96,50 +136,123 @@ func (s *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := s.store.GetUser(ctx, req.Username)
+	err := s.loginGuard.Check(ctx, req.Username, ctx.ClientIP())
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
+		loginGuardErrorResponse(ctx, err)
+		return
+	}
 
+	// an unknown or closed username gets the same response as a wrong password so usernames can't be enumerated
+	user, err := s.store.GetUser(ctx, req.Username)
+	if err != nil && err != sql.ErrNoRows {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	if err == sql.ErrNoRows || user.Status != db.UserActive {
+		hashutil.CheckDummyPassword(s.hasher, req.Password)
+		s.rejectLogin(ctx, req.Username, lockout.ErrIncorrectCredentials)
+		return
+	}
+
 	err = hashutil.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		s.rejectLogin(ctx, req.Username, lockout.ErrIncorrectCredentials)
 		return
 	}
 
-	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.Username, s.cfg.AccessTokenDuration)
+	err = s.rehashPassword(ctx, user, req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(user.Username, s.cfg.RefreshTokenDuration)
+	enabled, err := mfa.IsEnabled(ctx, s.store, user.Username)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	session, err := s.store.CreateSession(ctx, db.CreateSessionParams{
-		ID:           refreshPayload.ID,
-		Username:     user.Username,
-		RefreshToken: refreshToken,
-		UserAgent:    ctx.Request.UserAgent(),
-		ClientIp:     ctx.ClientIP(),
-		IsBlocked:    false,
-		ExpiresAt:    refreshPayload.ExpiredAt,
-	})
+	if enabled {
+		mfaToken, mfaPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, s.cfg.MfaTokenDuration, token.ScopeMfaPending)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, loginUserMfaRequiredResponse{
+			MfaRequired:       true,
+			MfaToken:          mfaToken,
+			MfaTokenExpiresAt: mfaPayload.ExpiredAt,
+		})
+		return
+	}
 
+	resp, err := s.createLoginSession(ctx, user)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	ctx.JSON(http.StatusAccepted, resp)
+}
+
+// rejectLogin counts a failed login towards the lockout of the username and the client ip
+func (s *Server) rejectLogin(ctx *gin.Context, username string, reason error) {
+	err := s.loginGuard.RecordFailure(ctx, username, ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusUnauthorized, errorResponse(reason))
+}
+
+func loginGuardErrorResponse(ctx *gin.Context, err error) {
+	var lockedErr *lockout.LockedError
+	if errors.As(err, &lockedErr) {
+		retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+		ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+}
+
+// createLoginSession issues the access and refresh tokens of a user who proved its identity
+func (s *Server) createLoginSession(ctx *gin.Context, user db.User) (loginUserResponse, error) {
+	err := s.loginGuard.RecordSuccess(ctx, user.Username)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, s.cfg.AccessTokenDuration)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, s.cfg.RefreshTokenDuration, token.ScopeRefresh)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	session, err := s.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:                   refreshPayload.ID,
+		Username:             user.Username,
+		RefreshToken:         refreshToken,
+		UserAgent:            ctx.Request.UserAgent(),
+		ClientIp:             ctx.ClientIP(),
+		IsBlocked:            false,
+		ExpiresAt:            refreshPayload.ExpiredAt,
+		FamilyID:             refreshPayload.ID,
+		AccessTokenID:        uuid.NullUUID{UUID: accessPayload.ID, Valid: true},
+		AccessTokenExpiresAt: sql.NullTime{Time: accessPayload.ExpiredAt, Valid: true},
+	})
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
 	resp := loginUserResponse{
 		SessionID:             session.ID,
 		AccessToken:           accessToken,
@@ -149,5 +262,268 @@ func (s *Server) loginUser(ctx *gin.Context) {
 		User:                  newUserResponse(user),
 	}
 
-	ctx.JSON(http.StatusAccepted, resp)
+	return resp, nil
+}
+
+type updateUserRoleUri struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type updateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,role"`
+}
+
+func (s *Server) updateUserRole(ctx *gin.Context) {
+	var uri updateUserRoleUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := s.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+		Username: uri.Username,
+		Role:     req.Role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type unlockUserUri struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// unlockUser lifts the login lockout of a user before it expires
+func (s *Server) unlockUser(ctx *gin.Context) {
+	var uri unlockUserUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	unlocked, err := s.loginGuard.Unlock(ctx, uri.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !unlocked {
+		err = errors.New("user has no failed login attempts")
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// getCurrentUser returns the profile of the authenticated user
+func (s *Server) getCurrentUser(ctx *gin.Context) {
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type updateUserRequest struct {
+	FullName *string `json:"full_name" binding:"omitempty,gt=0"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+}
+
+// updateCurrentUser updates the profile of the authenticated user.
+// It requires a full access token, the email receives the password reset links so integrations must not change it.
+func (s *Server) updateCurrentUser(ctx *gin.Context) {
+	var req updateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+	s.updateUser(ctx, authPayload.Username, req)
+}
+
+type adminUpdateUserUri struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// adminUpdateUser updates the profile of any user
+func (s *Server) adminUpdateUser(ctx *gin.Context) {
+	var uri adminUpdateUserUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	s.updateUser(ctx, uri.Username, req)
+}
+
+// updateUser only changes the provided fields, a new email has to be verified again
+func (s *Server) updateUser(ctx *gin.Context, username string, req updateUserRequest) {
+	// omitempty skips the validation of empty strings
+	if req.FullName != nil && len(*req.FullName) == 0 {
+		err := errors.New("full_name can't be empty")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Email != nil && len(*req.Email) == 0 {
+		err := errors.New("email can't be empty")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	secretCode, err := secretutil.Generate()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateUserTxParams{
+		UpdateUserParams:     db.UpdateUserParams{Username: username},
+		HashedSecretCode:     secretutil.Hash(secretCode),
+		VerifyEmailExpiresAt: time.Now().Add(s.cfg.VerifyEmailDuration),
+		AfterEmailChange: func(user db.User, verifyEmail db.VerifyEmail) error {
+			return s.sendVerifyEmail(ctx, user, verifyEmail, secretCode)
+		},
+	}
+	if req.FullName != nil {
+		arg.FullName = sql.NullString{String: *req.FullName, Valid: true}
+	}
+	if req.Email != nil {
+		arg.Email = sql.NullString{String: *req.Email, Valid: true}
+	}
+
+	result, err := s.store.UpdateUserTx(ctx, arg)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		pgErr, ok := err.(*pq.Error)
+		if ok {
+			switch pgErr.Code.Name() {
+			case "unique_violation":
+				ctx.JSON(http.StatusForbidden, errorResponse(err))
+				return
+			}
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
+
+type closeCurrentUserRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// closeCurrentUser closes the account of the authenticated user, the password is asked again
+// since a stolen access token must not be enough to erase the user. Scoped tokens and api keys are refused.
+func (s *Server) closeCurrentUser(ctx *gin.Context) {
+	var req closeCurrentUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+	err := s.loginGuard.Check(ctx, authPayload.Username, ctx.ClientIP())
+	if err != nil {
+		loginGuardErrorResponse(ctx, err)
+		return
+	}
+
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = hashutil.CheckPassword(req.Password, user.HashedPassword)
+	if err != nil {
+		s.rejectLogin(ctx, user.Username, errIncorrectPassword)
+		return
+	}
+
+	s.closeUser(ctx, user.Username)
+}
+
+type adminCloseUserUri struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// adminCloseUser closes the account of any user, e.g. for an erasure request received by the support
+func (s *Server) adminCloseUser(ctx *gin.Context) {
+	var uri adminCloseUserUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	s.closeUser(ctx, uri.Username)
+}
+
+// closeUser anonymizes the user and logs it out everywhere, its accounts must be emptied first
+func (s *Server) closeUser(ctx *gin.Context, username string) {
+	result, err := s.store.CloseUserTx(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		if errors.Is(err, db.ErrNonZeroBalance) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = s.revocations.RevokeSessions(ctx, result.BlockedSessions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
 }
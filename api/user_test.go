@@ -11,26 +11,33 @@ import (
 	"github.com/stretchr/testify/require"
 	mockdb "github.com/thehaung/simplebank/db/mock"
 	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/passwordpolicy"
+	"github.com/thehaung/simplebank/token"
 	"github.com/thehaung/simplebank/util/hashutil"
 	"github.com/thehaung/simplebank/util/randutil"
+	"github.com/thehaung/simplebank/util/roleutil"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
-type eqCreateUserParamsMatcher struct {
+type eqCreateUserTxParamsMatcher struct {
 	arg      db.CreateUserParams
 	password string
 }
 
-func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateUserParams)
+func (e eqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
+	txArg, ok := x.(db.CreateUserTxParams)
 	if !ok {
 		return false
 	}
 
+	arg := txArg.CreateUserParams
+
 	err := hashutil.CheckPassword(e.password, arg.HashedPassword)
 	if err != nil {
 		return false
@@ -40,12 +47,12 @@ func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
 	return reflect.DeepEqual(e.arg, arg)
 }
 
-func (e eqCreateUserParamsMatcher) String() string {
+func (e eqCreateUserTxParamsMatcher) String() string {
 	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
 }
 
-func EqCreateUserParams(arg db.CreateUserParams, password string) gomock.Matcher {
-	return eqCreateUserParamsMatcher{arg, password}
+func EqCreateUserTxParams(arg db.CreateUserParams, password string) gomock.Matcher {
+	return eqCreateUserTxParamsMatcher{arg, password}
 }
 
 func TestCreateUserAPI(t *testing.T) {
@@ -72,9 +79,12 @@ func TestCreateUserAPI(t *testing.T) {
 					Email:    user.Email,
 				}
 				store.EXPECT().
-					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
+					CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).
 					Times(1).
-					Return(user, nil)
+					DoAndReturn(func(_ interface{}, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						verifyEmail := db.VerifyEmail{ID: 1, Username: user.Username, Email: user.Email, HashedSecretCode: arg.HashedSecretCode}
+						return db.CreateUserTxResult{User: user, VerifyEmail: verifyEmail}, arg.AfterCreate(user, verifyEmail)
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusCreated, recorder.Code)
@@ -91,9 +101,9 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, sql.ErrConnDone)
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -109,9 +119,9 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, &pq.Error{Code: "23505"})
+					Return(db.CreateUserTxResult{}, &pq.Error{Code: "23505"})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusForbidden, recorder.Code)
@@ -127,7 +137,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -144,7 +154,7 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -161,11 +171,30 @@ func TestCreateUserAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					CreateUser(gomock.Any(), gomock.Any()).
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireFieldViolation(t, recorder, "password", passwordpolicy.RuleMinLength)
+			},
+		},
+		{
+			name: "PasswordContainsEmail",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  strings.Split(user.Email, "@")[0] + "2024",
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireFieldViolation(t, recorder, "password", passwordpolicy.RuleUserInfo)
 			},
 		},
 	}
@@ -207,6 +236,7 @@ func randomUser(t *testing.T) (user db.User, password string) {
 		HashedPassword: hashedPassword,
 		FullName:       randutil.Owner(),
 		Email:          randutil.Email(),
+		Status:         db.UserActive,
 	}
 	return
 }
@@ -224,3 +254,444 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	require.Equal(t, user.Email, gotUser.Email)
 	require.Empty(t, gotUser.HashedPassword)
 }
+
+func TestGetCurrentUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			s := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users/me", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, s.tokenMaker, _authorizationHeaderBearer, user.Username, roleutil.Depositor, time.Minute)
+			s.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.IsEmailVerified = true
+	newEmail := randutil.Email()
+
+	updated := user
+	updated.Email = newEmail
+	updated.IsEmailVerified = false
+
+	testCases := []struct {
+		name          string
+		url           string
+		username      string
+		role          string
+		scopes        []string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, notifier *recordingNotifier)
+	}{
+		{
+			name:     "FullName",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			body:     gin.H{"full_name": "New Name"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, sql.NullString{String: "New Name", Valid: true}, arg.FullName)
+						require.False(t, arg.Email.Valid)
+
+						renamed := user
+						renamed.FullName = "New Name"
+						return db.UpdateUserTxResult{User: renamed}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"full_name":"New Name"`)
+				require.NotContains(t, recorder.Body.String(), "hashed_password")
+				require.Empty(t, notifier.messages)
+			},
+		},
+		{
+			name:     "EmailRequiresVerification",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			body:     gin.H{"email": newEmail},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, sql.NullString{String: newEmail, Valid: true}, arg.Email)
+						require.False(t, arg.FullName.Valid)
+
+						verifyEmail := db.VerifyEmail{ID: 9, Username: updated.Username, Email: newEmail}
+						return db.UpdateUserTxResult{User: updated, VerifyEmail: &verifyEmail}, arg.AfterEmailChange(updated, verifyEmail)
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"is_email_verified":false`)
+				require.Len(t, notifier.messages, 1)
+				require.Equal(t, newEmail, notifier.messages[0].Email)
+			},
+		},
+		{
+			name:     "EmptyFullName",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			body:     gin.H{"full_name": ""},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidEmail",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			body:     gin.H{"email": "invalid-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "DuplicateEmail",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			body:     gin.H{"email": newEmail},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "ScopedToken",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			scopes:   []string{token.ScopeAccountsRead},
+			body:     gin.H{"email": newEmail},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "AdminUpdatesAnyUser",
+			url:      "/admin/users/" + user.Username,
+			username: "root",
+			role:     roleutil.Admin,
+			body:     gin.H{"full_name": "New Name"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.UpdateUserTxResult{User: user}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "AdminUserNotFound",
+			url:      "/admin/users/" + user.Username,
+			username: "root",
+			role:     roleutil.Admin,
+			body:     gin.H{"full_name": "New Name"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "NotAdmin",
+			url:      "/admin/users/" + user.Username,
+			username: "root",
+			role:     roleutil.Banker,
+			body:     gin.H{"full_name": "New Name"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, notifier *recordingNotifier) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			s := newTestServer(t, store)
+			notifier := &recordingNotifier{}
+			s.notifier = notifier
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPatch, tc.url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			accessToken, _, err := s.tokenMaker.CreateToken(tc.username, tc.role, time.Minute, tc.scopes...)
+			require.NoError(t, err)
+
+			request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationHeaderBearer, accessToken))
+			s.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, notifier)
+		})
+	}
+}
+
+func TestCloseUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+	session := blockedSession(user.Username)
+
+	closed := user
+	closed.Status = db.UserClosed
+	closed.FullName = ""
+
+	testCases := []struct {
+		name          string
+		url           string
+		username      string
+		role          string
+		scopes        []string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			body:     gin.H{"password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					CloseUserTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.CloseUserTxResult{User: closed, BlockedSessions: []db.Session{session}}, nil)
+				store.EXPECT().
+					RevokeToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.RevokeTokenParams) error {
+						require.Equal(t, session.AccessTokenID.UUID, arg.ID)
+						return nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:     "ScopedToken",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			scopes:   []string{token.ScopeAccountsWrite},
+			body:     gin.H{"password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CloseUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "WrongPassword",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			body:     gin.H{"password": "wrong-password"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CloseUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "MissingPassword",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			body:     gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CloseUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "NonZeroBalance",
+			url:      "/users/me",
+			username: user.Username,
+			role:     roleutil.Depositor,
+			body:     gin.H{"password": password},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					CloseUserTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.CloseUserTxResult{}, db.ErrNonZeroBalance)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "Admin",
+			url:      "/admin/users/" + user.Username,
+			username: "root",
+			role:     roleutil.Admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					CloseUserTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.CloseUserTxResult{User: closed, BlockedSessions: []db.Session{session}}, nil)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:     "AdminAlreadyClosed",
+			url:      "/admin/users/" + user.Username,
+			username: "root",
+			role:     roleutil.Admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CloseUserTx(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.CloseUserTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "AdminNotAllowed",
+			url:      "/admin/users/" + user.Username,
+			username: "banker",
+			role:     roleutil.Banker,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CloseUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			s := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body io.Reader
+			if tc.body != nil {
+				data, err := json.Marshal(tc.body)
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			}
+
+			request, err := http.NewRequest(http.MethodDelete, tc.url, body)
+			require.NoError(t, err)
+			request.RemoteAddr = "10.0.0.1:12345"
+
+			accessToken, _, err := s.tokenMaker.CreateToken(tc.username, tc.role, time.Minute, tc.scopes...)
+			require.NoError(t, err)
+
+			request.Header.Set(_authorizationHeaderKey, fmt.Sprintf("%s %s", _authorizationHeaderBearer, accessToken))
+			s.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
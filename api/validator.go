@@ -1,29 +1,48 @@
 package api
 
-import "github.com/go-playground/validator/v10"
-
-// Currency constants
-const (
-	USD = "USD"
-	EUR = "EUR"
-	CAD = "CAD"
-	VND = "VND"
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/currencyutil"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"github.com/thehaung/simplebank/util/scheduleutil"
 )
 
 var validCurrency validator.Func = func(fl validator.FieldLevel) bool {
 	currency, ok := fl.Field().Interface().(string)
 
 	if ok {
-		return IsSupportCurrency(currency)
+		return currencyutil.IsSupportCurrency(currency)
 	}
 
 	return false
 }
 
-func IsSupportCurrency(currency string) bool {
-	switch currency {
-	case CAD, USD, EUR, VND:
-		return true
+var validSchedule validator.Func = func(fl validator.FieldLevel) bool {
+	schedule, ok := fl.Field().Interface().(string)
+
+	if ok {
+		return scheduleutil.Validate(schedule) == nil
+	}
+
+	return false
+}
+
+var validRole validator.Func = func(fl validator.FieldLevel) bool {
+	role, ok := fl.Field().Interface().(string)
+
+	if ok {
+		return roleutil.IsSupportRole(role)
+	}
+
+	return false
+}
+
+var validScope validator.Func = func(fl validator.FieldLevel) bool {
+	scope, ok := fl.Field().Interface().(string)
+
+	if ok {
+		return token.IsSupportScope(scope)
 	}
 
 	return false
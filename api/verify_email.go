@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/gin-gonic/gin"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/notify"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/secretutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var errEmailAlreadyVerified = errors.New("email is already verified")
+
+// sendVerifyEmail sends the link the user verifies its email with, the secret code is only known by the message
+func (s *Server) sendVerifyEmail(ctx context.Context, user db.User, verifyEmail db.VerifyEmail, secretCode string) error {
+	link := notify.Link(s.cfg.VerifyEmailURL, url.Values{
+		"email_id":    {strconv.FormatInt(verifyEmail.ID, 10)},
+		"secret_code": {secretCode},
+	})
+
+	return s.notifier.Notify(ctx, notify.VerifyEmailMessage(user.Username, user.FullName, verifyEmail.Email, link, verifyEmail.ExpiresAt))
+}
+
+type verifyEmailRequest struct {
+	EmailID    int64  `form:"email_id" binding:"required,min=1"`
+	SecretCode string `form:"secret_code" binding:"required"`
+}
+
+// verifyEmail is the target of the link sent to the users, the code can only be used once
+func (s *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := s.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		EmailID:          req.EmailID,
+		HashedSecretCode: secretutil.Hash(req.SecretCode),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidVerifyEmail) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
+
+// resendVerifyEmail sends a new verification link to the authenticated user, e.g. when the first one expired
+func (s *Server) resendVerifyEmail(ctx *gin.Context) {
+	authPayload := ctx.MustGet(_authorizationPayloadKey).(*token.Payload)
+
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsEmailVerified {
+		ctx.JSON(http.StatusConflict, errorResponse(errEmailAlreadyVerified))
+		return
+	}
+
+	secretCode, err := secretutil.Generate()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	verifyEmail, err := s.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:         user.Username,
+		Email:            user.Email,
+		HashedSecretCode: secretutil.Hash(secretCode),
+		ExpiresAt:        time.Now().Add(s.cfg.VerifyEmailDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = s.sendVerifyEmail(ctx, user, verifyEmail, secretCode)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	_ "github.com/lib/pq"
 	"github.com/thehaung/simplebank/api"
 	"github.com/thehaung/simplebank/config"
 	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/gapi"
+	"github.com/thehaung/simplebank/scheduler"
 	"log"
 )
 
@@ -20,13 +24,37 @@ func main() {
 	}
 
 	dbStore := db.NewStore(conn)
-	httpServer, err := api.NewHttpServer(conf, dbStore)
+
+	errs := make(chan error, 2)
+	go runHttpServer(conf, dbStore, errs)
+	go runGrpcServer(conf, dbStore, errs)
+	go scheduler.NewScheduler(dbStore, conf.SchedulerInterval).Start(context.Background())
+
+	log.Fatal(<-errs)
+}
+
+func runHttpServer(conf *config.Config, store db.Store, errs chan<- error) {
+	httpServer, err := api.NewHttpServer(conf, store)
 	if err != nil {
 		log.Fatal("main - api.NewHttpServer(). Error:", err)
 	}
 
+	log.Printf("start HTTP server at %s", conf.HttpServerAddress)
 	err = httpServer.Start(conf.HttpServerAddress)
 	if err != nil {
-		log.Fatal("main - httpServer.Start(). Error:", err)
+		errs <- fmt.Errorf("main - httpServer.Start(). Error: %w", err)
+	}
+}
+
+func runGrpcServer(conf *config.Config, store db.Store, errs chan<- error) {
+	grpcServer, err := gapi.NewGrpcServer(conf, store)
+	if err != nil {
+		log.Fatal("main - gapi.NewGrpcServer(). Error:", err)
+	}
+
+	log.Printf("start gRPC server at %s", conf.GrpcServerAddress)
+	err = grpcServer.Start(conf.GrpcServerAddress)
+	if err != nil {
+		errs <- fmt.Errorf("main - grpcServer.Start(). Error: %w", err)
 	}
 }
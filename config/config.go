@@ -6,13 +6,52 @@ import (
 )
 
 type Config struct {
-	DbDriver             string        `mapstructure:"DB_DRIVER"`
-	DbAddress            string        `mapstructure:"DB_ADDRESS"`
-	HttpServerAddress    string        `mapstructure:"HTTP_SERVER_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	TokenSecretKey       string        `mapstructure:"TOKEN_SECRET_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	DbDriver               string        `mapstructure:"DB_DRIVER"`
+	DbAddress              string        `mapstructure:"DB_ADDRESS"`
+	HttpServerAddress      string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	GrpcServerAddress      string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	TokenMakerType         string        `mapstructure:"TOKEN_MAKER_TYPE"`
+	TokenSymmetricKey      string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenKeys              []string      `mapstructure:"TOKEN_KEYS"`
+	TokenKeyFiles          []string      `mapstructure:"TOKEN_KEY_FILES"`
+	TokenActiveKeyID       string        `mapstructure:"TOKEN_ACTIVE_KEY_ID"`
+	TokenRetiredKeyIDs     []string      `mapstructure:"TOKEN_RETIRED_KEY_IDS"`
+	TokenIssuer            string        `mapstructure:"TOKEN_ISSUER"`
+	TokenAudience          string        `mapstructure:"TOKEN_AUDIENCE"`
+	AccessTokenDuration    time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration   time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	IdempotencyKeyDuration time.Duration `mapstructure:"IDEMPOTENCY_KEY_DURATION"`
+	FxQuoteDuration        time.Duration `mapstructure:"FX_QUOTE_DURATION"`
+	SchedulerInterval      time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	RevocationCacheTTL     time.Duration `mapstructure:"REVOCATION_CACHE_TTL"`
+	OAuthClients           []string      `mapstructure:"OAUTH_CLIENTS"`
+	MfaTokenDuration       time.Duration `mapstructure:"MFA_TOKEN_DURATION"`
+	TotpIssuer             string        `mapstructure:"TOTP_ISSUER"`
+	LoginMaxAttempts       int           `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginMaxAttemptsPerIP  int           `mapstructure:"LOGIN_MAX_ATTEMPTS_PER_IP"`
+	LoginLockoutDuration   time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LoginMaxLockout        time.Duration `mapstructure:"LOGIN_MAX_LOCKOUT"`
+	LoginAttemptWindow     time.Duration `mapstructure:"LOGIN_ATTEMPT_WINDOW"`
+	NotifierType           string        `mapstructure:"NOTIFIER_TYPE"`
+	PasswordResetDuration  time.Duration `mapstructure:"PASSWORD_RESET_DURATION"`
+	PasswordResetURL       string        `mapstructure:"PASSWORD_RESET_URL"`
+	NotifierDir            string        `mapstructure:"NOTIFIER_DIR"`
+	VerifyEmailDuration    time.Duration `mapstructure:"VERIFY_EMAIL_DURATION"`
+	VerifyEmailURL         string        `mapstructure:"VERIFY_EMAIL_URL"`
+	RequireVerifiedEmail   bool          `mapstructure:"REQUIRE_VERIFIED_EMAIL"`
+	PasswordHasher         string        `mapstructure:"PASSWORD_HASHER"`
+	BcryptCost             int           `mapstructure:"BCRYPT_COST"`
+	Argon2Memory           uint32        `mapstructure:"ARGON2_MEMORY"`
+	Argon2Iterations       uint32        `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Parallelism      uint8         `mapstructure:"ARGON2_PARALLELISM"`
+	PasswordMinLength      int           `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength      int           `mapstructure:"PASSWORD_MAX_LENGTH"`
+	PasswordRequireUpper   bool          `mapstructure:"PASSWORD_REQUIRE_UPPER"`
+	PasswordRequireLower   bool          `mapstructure:"PASSWORD_REQUIRE_LOWER"`
+	PasswordRequireDigit   bool          `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol  bool          `mapstructure:"PASSWORD_REQUIRE_SYMBOL"`
+	PasswordMaxRepetition  int           `mapstructure:"PASSWORD_MAX_REPETITION"`
+	PasswordCheckBreached  bool          `mapstructure:"PASSWORD_CHECK_BREACHED"`
 }
 
 func Parse(path string) (*Config, error) {
@@ -0,0 +1,18 @@
+CREATE TABLE "idempotency_keys"
+(
+    "username"        varchar     NOT NULL,
+    "idempotency_key" varchar     NOT NULL,
+    "request_hash"    varchar     NOT NULL,
+    "response_status" integer,
+    "response_body"   bytea,
+    "expires_at"      timestamptz NOT NULL,
+    "created_at"      timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("username", "idempotency_key")
+);
+
+ALTER TABLE "idempotency_keys"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "idempotency_keys" ("expires_at");
+
+COMMENT ON COLUMN "idempotency_keys"."response_status" IS 'null while the original request is in progress';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "external_movements";
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE "external_movements"
+(
+    "id"         bigserial PRIMARY KEY,
+    "account_id" bigint      NOT NULL,
+    "entry_id"   bigint      NOT NULL,
+    "amount"     bigint      NOT NULL,
+    "source"     varchar     NOT NULL,
+    "reference"  varchar     NOT NULL DEFAULT '',
+    "channel"    varchar     NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "external_movements"
+    ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "external_movements"
+    ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+CREATE INDEX ON "external_movements" ("account_id");
+
+COMMENT ON COLUMN "external_movements"."amount" IS 'positive for deposits, negative for withdrawals';
+
+COMMENT ON COLUMN "external_movements"."source" IS 'where the money comes from or goes to, e.g. an external bank account';
@@ -0,0 +1,38 @@
+CREATE TABLE "fx_rates"
+(
+    "id"             bigserial PRIMARY KEY,
+    "base_currency"  varchar     NOT NULL,
+    "quote_currency" varchar     NOT NULL,
+    "rate"           numeric     NOT NULL,
+    "valid_from"     timestamptz NOT NULL,
+    "created_at"     timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "fx_rates" ("base_currency", "quote_currency", "valid_from");
+
+COMMENT ON COLUMN "fx_rates"."rate" IS 'amount of quote currency bought by one unit of base currency, must be positive';
+
+ALTER TABLE "fx_rates"
+    ADD CONSTRAINT "rate_positive" CHECK ("rate" > 0);
+
+CREATE TABLE "fx_quotes"
+(
+    "id"            uuid PRIMARY KEY,
+    "username"      varchar     NOT NULL,
+    "from_currency" varchar     NOT NULL,
+    "to_currency"   varchar     NOT NULL,
+    "rate"          numeric     NOT NULL,
+    "from_amount"   bigint      NOT NULL,
+    "to_amount"     bigint      NOT NULL,
+    "transfer_id"   bigint,
+    "expires_at"    timestamptz NOT NULL,
+    "created_at"    timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "fx_quotes"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "fx_quotes"
+    ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+COMMENT ON COLUMN "fx_quotes"."transfer_id" IS 'set once the quote has been used by a transfer';
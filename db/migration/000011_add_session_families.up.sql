@@ -0,0 +1,17 @@
+ALTER TABLE "sessions"
+    ADD COLUMN "family_id" uuid;
+
+UPDATE "sessions"
+SET "family_id" = "id";
+
+ALTER TABLE "sessions"
+    ALTER COLUMN "family_id" SET NOT NULL;
+
+ALTER TABLE "sessions"
+    ADD COLUMN "replaced_by" uuid;
+
+CREATE INDEX ON "sessions" ("family_id");
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'id of the login session every rotated session descends from';
+
+COMMENT ON COLUMN "sessions"."replaced_by" IS 'session issued when this refresh token was rotated';
@@ -0,0 +1,22 @@
+CREATE TABLE "revoked_tokens"
+(
+    "id"         uuid PRIMARY KEY,
+    "username"   varchar     NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "revoked_tokens"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "revoked_tokens" ("expires_at");
+
+ALTER TABLE "sessions"
+    ADD COLUMN "access_token_id" uuid;
+
+ALTER TABLE "sessions"
+    ADD COLUMN "access_token_expires_at" timestamptz;
+
+COMMENT ON COLUMN "revoked_tokens"."id" IS 'id of the revoked access token';
+
+COMMENT ON COLUMN "sessions"."access_token_id" IS 'access token issued together with the refresh token of the session';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "sessions_access_token_id_idx";
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE INDEX ON "sessions" ("access_token_id");
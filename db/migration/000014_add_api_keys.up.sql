@@ -0,0 +1,25 @@
+CREATE TABLE "api_keys"
+(
+    "id"         uuid PRIMARY KEY,
+    "username"   varchar     NOT NULL,
+    "name"       varchar     NOT NULL,
+    "prefix"     varchar     NOT NULL,
+    "hashed_key" varchar     NOT NULL,
+    "scopes"     varchar[]   NOT NULL DEFAULT '{}',
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "api_keys"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "api_keys" ("prefix");
+
+CREATE INDEX ON "api_keys" ("username");
+
+COMMENT ON COLUMN "api_keys"."prefix" IS 'public part of the key used to look it up';
+
+COMMENT ON COLUMN "api_keys"."hashed_key" IS 'sha256 of the whole key, the key itself is only shown once';
+
+COMMENT ON COLUMN "api_keys"."scopes" IS 'empty means the key grants every scope of its owner';
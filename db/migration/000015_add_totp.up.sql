@@ -0,0 +1,31 @@
+CREATE TABLE "totp_secrets"
+(
+    "username"       varchar PRIMARY KEY,
+    "secret"         varchar     NOT NULL,
+    "last_used_step" bigint      NOT NULL DEFAULT 0,
+    "confirmed_at"   timestamptz,
+    "created_at"     timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "totp_secrets"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE TABLE "recovery_codes"
+(
+    "id"          bigserial PRIMARY KEY,
+    "username"    varchar     NOT NULL,
+    "hashed_code" varchar     NOT NULL,
+    "used_at"     timestamptz,
+    "created_at"  timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "recovery_codes"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("username", "hashed_code");
+
+COMMENT ON COLUMN "totp_secrets"."secret" IS 'base32 encoded shared secret, it has to be readable to compute the codes';
+
+COMMENT ON COLUMN "totp_secrets"."last_used_step" IS 'time step of the last accepted code, older or equal steps are replays';
+
+COMMENT ON COLUMN "totp_secrets"."confirmed_at" IS 'null until the user proved the authenticator app works, login only asks for a code once set';
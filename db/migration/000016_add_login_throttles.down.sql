@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "login_throttles";
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE "login_throttles"
+(
+    "scope"        varchar     NOT NULL,
+    "key"          varchar     NOT NULL,
+    "failures"     int         NOT NULL DEFAULT 0,
+    "locked_until" timestamptz,
+    "updated_at"   timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("scope", "key")
+);
+
+COMMENT ON COLUMN "login_throttles"."scope" IS 'username or ip';
+
+COMMENT ON COLUMN "login_throttles"."failures" IS 'consecutive failed logins, reset after a quiet window or a successful login';
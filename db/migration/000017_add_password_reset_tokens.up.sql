@@ -0,0 +1,20 @@
+CREATE TABLE "password_reset_tokens"
+(
+    "id"           bigserial PRIMARY KEY,
+    "username"     varchar     NOT NULL,
+    "hashed_token" varchar     NOT NULL,
+    "expires_at"   timestamptz NOT NULL,
+    "used_at"      timestamptz,
+    "created_at"   timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "password_reset_tokens"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "password_reset_tokens" ("hashed_token");
+
+CREATE INDEX ON "password_reset_tokens" ("username");
+
+COMMENT ON COLUMN "password_reset_tokens"."hashed_token" IS 'sha256 of the token, the token itself is only sent to the user';
+
+COMMENT ON COLUMN "password_reset_tokens"."used_at" IS 'set once the token reset the password, or when another reset made it obsolete';
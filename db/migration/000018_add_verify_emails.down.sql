@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "verify_emails";
+
+ALTER TABLE "users"
+    DROP COLUMN "is_email_verified";
\ No newline at end of file
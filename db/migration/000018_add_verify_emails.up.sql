@@ -0,0 +1,22 @@
+ALTER TABLE "users"
+    ADD COLUMN "is_email_verified" bool NOT NULL DEFAULT false;
+
+CREATE TABLE "verify_emails"
+(
+    "id"                 bigserial PRIMARY KEY,
+    "username"           varchar     NOT NULL,
+    "email"              varchar     NOT NULL,
+    "hashed_secret_code" varchar     NOT NULL,
+    "is_used"            bool        NOT NULL DEFAULT false,
+    "expires_at"         timestamptz NOT NULL,
+    "created_at"         timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "verify_emails"
+    ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "verify_emails" ("username");
+
+COMMENT ON COLUMN "verify_emails"."email" IS 'address the code was sent to, the user is only verified if it still has this email';
+
+COMMENT ON COLUMN "verify_emails"."hashed_secret_code" IS 'sha256 of the secret code, the code itself is only sent to the user';
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "closed_at";
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,10 @@
+ALTER TABLE "users"
+    ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+ALTER TABLE "users"
+    ADD CONSTRAINT "users_status_check" CHECK ("status" IN ('active', 'closed'));
+
+ALTER TABLE "users"
+    ADD COLUMN "closed_at" timestamptz;
+
+COMMENT ON COLUMN "users"."status" IS 'active or closed, a closed user is anonymized and can''t log in';
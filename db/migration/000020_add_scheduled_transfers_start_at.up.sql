@@ -0,0 +1,10 @@
+ALTER TABLE "scheduled_transfers"
+    ADD COLUMN "start_at" timestamptz;
+
+UPDATE "scheduled_transfers"
+SET "start_at" = "next_run_at";
+
+ALTER TABLE "scheduled_transfers"
+    ALTER COLUMN "start_at" SET NOT NULL;
+
+COMMENT ON COLUMN "scheduled_transfers"."start_at" IS 'first run, @monthly schedules keep its day of month';
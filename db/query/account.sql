@@ -34,4 +34,25 @@ WHERE id = sqlc.arg(id) RETURNING *;
 -- name: DeleteAccount :exec
 DELETE
 FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = sqlc.arg(overdraft_limit)
+WHERE id = sqlc.arg(id) RETURNING *;
+
+-- name: ListAllAccounts :many
+SELECT *
+FROM accounts
+WHERE sqlc.narg(owner)::varchar IS NULL
+   OR owner = sqlc.narg(owner)
+ORDER BY id LIMIT sqlc.arg(limit_count)
+OFFSET sqlc.arg(offset_count);
+
+-- name: ListAccountsForUpdateByOwner :many
+SELECT *
+FROM accounts
+WHERE owner = $1
+ORDER BY id
+FOR NO KEY
+UPDATE;
@@ -0,0 +1,28 @@
+-- name: CreateApiKey :one
+INSERT INTO api_keys (id, username, name, prefix, hashed_key, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+
+-- name: GetApiKeyByPrefix :one
+SELECT api_keys.*, users.role
+FROM api_keys
+         JOIN users ON users.username = api_keys.username
+WHERE api_keys.prefix = $1 LIMIT 1;
+
+-- name: ListApiKeys :many
+SELECT *
+FROM api_keys
+WHERE username = $1
+ORDER BY created_at DESC;
+
+-- name: RevokeApiKey :one
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = sqlc.arg(id)
+  AND username = sqlc.arg(username)
+  AND revoked_at IS NULL RETURNING *;
+
+-- name: RevokeUserApiKeys :exec
+UPDATE api_keys
+SET revoked_at = now()
+WHERE username = $1
+  AND revoked_at IS NULL;
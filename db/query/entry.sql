@@ -13,4 +13,19 @@ SELECT *
 FROM entries
 WHERE account_id = $1
 ORDER BY id LIMIT $2
-OFFSET $3;
\ No newline at end of file
+OFFSET $3;
+
+-- name: ListAccountEntries :many
+SELECT *
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+  AND (sqlc.arg(direction)::text = ''
+    OR (sqlc.arg(direction) = 'incoming' AND amount > 0)
+    OR (sqlc.arg(direction) = 'outgoing' AND amount < 0))
+ORDER BY CASE WHEN sqlc.arg(sort_by)::text = 'amount' AND sqlc.arg(sort_desc)::bool THEN amount END DESC,
+         CASE WHEN sqlc.arg(sort_by) = 'amount' AND NOT sqlc.arg(sort_desc) THEN amount END,
+         CASE WHEN sqlc.arg(sort_desc) THEN id END DESC,
+         id
+LIMIT sqlc.arg(limit_count) OFFSET sqlc.arg(offset_count);
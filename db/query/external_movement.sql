@@ -0,0 +1,15 @@
+-- name: CreateExternalMovement :one
+INSERT INTO external_movements (account_id, entry_id, amount, source, reference, channel)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;
+
+-- name: GetExternalMovement :one
+SELECT *
+FROM external_movements
+WHERE id = $1 LIMIT 1;
+
+-- name: ListExternalMovements :many
+SELECT *
+FROM external_movements
+WHERE account_id = $1
+ORDER BY id LIMIT $2
+OFFSET $3;
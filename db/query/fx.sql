@@ -0,0 +1,32 @@
+-- name: CreateFxRate :one
+INSERT INTO fx_rates (base_currency, quote_currency, rate, valid_from)
+VALUES ($1, $2, $3, $4) RETURNING *;
+
+-- name: GetCurrentFxRate :one
+SELECT *
+FROM fx_rates
+WHERE base_currency = $1
+  AND quote_currency = $2
+  AND valid_from <= now()
+ORDER BY valid_from DESC LIMIT 1;
+
+-- name: CreateFxQuote :one
+INSERT INTO fx_quotes (id, username, from_currency, to_currency, rate, from_amount, to_amount, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;
+
+-- name: GetFxQuote :one
+SELECT *
+FROM fx_quotes
+WHERE id = $1 LIMIT 1;
+
+-- name: GetFxQuoteForUpdate :one
+SELECT *
+FROM fx_quotes
+WHERE id = $1 LIMIT 1
+FOR NO KEY
+UPDATE;
+
+-- name: UseFxQuote :one
+UPDATE fx_quotes
+SET transfer_id = sqlc.arg(transfer_id)
+WHERE id = sqlc.arg(id) RETURNING *;
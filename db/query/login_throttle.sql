@@ -0,0 +1,27 @@
+-- name: GetLoginThrottle :one
+SELECT *
+FROM login_throttles
+WHERE scope = $1
+  AND key = $2 LIMIT 1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (scope, key, failures)
+VALUES (sqlc.arg(scope), sqlc.arg(key), 1) ON CONFLICT (scope, key) DO
+UPDATE
+SET failures   = CASE
+                     WHEN login_throttles.updated_at < sqlc.arg(window_start)::timestamptz THEN 1
+                     ELSE login_throttles.failures + 1
+    END,
+    updated_at = now() RETURNING *;
+
+-- name: LockLogin :one
+UPDATE login_throttles
+SET locked_until = sqlc.arg(locked_until)
+WHERE scope = sqlc.arg(scope)
+  AND key = sqlc.arg(key) RETURNING *;
+
+-- name: DeleteLoginThrottle :execrows
+DELETE
+FROM login_throttles
+WHERE scope = $1
+  AND key = $2;
@@ -0,0 +1,24 @@
+-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (username, hashed_token, expires_at)
+VALUES ($1, $2, $3) RETURNING *;
+
+-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE hashed_token = $1
+  AND used_at IS NULL
+  AND expires_at > now() RETURNING *;
+
+-- name: InvalidatePasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE username = $1
+  AND used_at IS NULL;
+
+-- name: GetUserByPasswordResetToken :one
+SELECT users.*
+FROM password_reset_tokens
+         JOIN users ON users.username = password_reset_tokens.username
+WHERE password_reset_tokens.hashed_token = $1
+  AND password_reset_tokens.used_at IS NULL
+  AND password_reset_tokens.expires_at > now() LIMIT 1;
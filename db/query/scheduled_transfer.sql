@@ -0,0 +1,49 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (owner, from_account_id, to_account_id, amount, currency, schedule, next_run_at, start_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $7) RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT *
+FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: ListScheduledTransfers :many
+SELECT *
+FROM scheduled_transfers
+WHERE owner = $1
+ORDER BY id LIMIT $2
+OFFSET $3;
+
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET amount      = COALESCE(sqlc.narg(amount), amount),
+    schedule    = COALESCE(sqlc.narg(schedule), schedule),
+    next_run_at = COALESCE(sqlc.narg(next_run_at), next_run_at),
+    status      = COALESCE(sqlc.narg(status), status)
+WHERE id = sqlc.arg(id) RETURNING *;
+
+-- name: ClaimDueScheduledTransfer :one
+SELECT *
+FROM scheduled_transfers
+WHERE status = 'active'
+  AND next_run_at <= now()
+ORDER BY next_run_at LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (scheduled_transfer_id, transfer_id, status, error_message)
+VALUES ($1, $2, $3, $4) RETURNING *;
+
+-- name: ListScheduledTransferRuns :many
+SELECT *
+FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1
+ORDER BY id DESC LIMIT $2
+OFFSET $3;
+
+-- name: CancelUserScheduledTransfers :exec
+UPDATE scheduled_transfers
+SET status = 'cancelled'
+WHERE status <> 'cancelled'
+  AND (scheduled_transfers.owner = sqlc.arg(username)
+    OR to_account_id IN (SELECT id FROM accounts WHERE accounts.owner = sqlc.arg(username)));
@@ -1,8 +1,54 @@
 -- name: CreateSession :one
-INSERT INTO sessions (id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING *;
+INSERT INTO sessions (id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, family_id,
+                      access_token_id, access_token_expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING *;
 
 -- name: GetSession :one
 SELECT *
 FROM sessions
-WHERE id = $1 LIMIT 1;
\ No newline at end of file
+WHERE id = $1 LIMIT 1;
+
+-- name: ListActiveSessions :many
+SELECT *
+FROM sessions
+WHERE username = $1
+  AND is_blocked = false
+  AND replaced_by IS NULL
+  AND expires_at > now()
+ORDER BY created_at DESC;
+
+-- name: BlockSession :one
+UPDATE sessions
+SET is_blocked = true
+WHERE id = sqlc.arg(id)
+  AND username = sqlc.arg(username) RETURNING *;
+
+-- name: BlockUserSessions :many
+UPDATE sessions
+SET is_blocked = true
+WHERE username = $1
+  AND is_blocked = false RETURNING *;
+
+-- name: RotateSession :one
+UPDATE sessions
+SET replaced_by = sqlc.arg(replaced_by)::uuid
+WHERE id = sqlc.arg(id)
+  AND replaced_by IS NULL
+  AND is_blocked = false RETURNING *;
+
+-- name: BlockSessionFamily :many
+UPDATE sessions
+SET is_blocked = true
+WHERE family_id = $1 RETURNING *;
+
+-- name: GetSessionByAccessToken :one
+SELECT *
+FROM sessions
+WHERE access_token_id = $1 LIMIT 1;
+
+-- name: BlockOtherUserSessions :many
+UPDATE sessions
+SET is_blocked = true
+WHERE username = sqlc.arg(username)
+  AND is_blocked = false
+  AND access_token_id IS DISTINCT FROM sqlc.arg(current_access_token_id)::uuid RETURNING *;
@@ -0,0 +1,42 @@
+-- name: UpsertTotpSecret :one
+INSERT INTO totp_secrets (username, secret)
+VALUES ($1, $2) ON CONFLICT (username) DO
+UPDATE
+SET secret         = excluded.secret,
+    last_used_step = 0,
+    created_at     = now()
+WHERE totp_secrets.confirmed_at IS NULL RETURNING *;
+
+-- name: GetTotpSecret :one
+SELECT *
+FROM totp_secrets
+WHERE username = $1 LIMIT 1;
+
+-- name: ConfirmTotpSecret :one
+UPDATE totp_secrets
+SET confirmed_at   = now(),
+    last_used_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username)
+  AND confirmed_at IS NULL RETURNING *;
+
+-- name: UseTotpStep :one
+UPDATE totp_secrets
+SET last_used_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username)
+  AND last_used_step < sqlc.arg(step) RETURNING *;
+
+-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (username, hashed_code)
+VALUES ($1, $2) RETURNING *;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE
+FROM recovery_codes
+WHERE username = $1;
+
+-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET used_at = now()
+WHERE username = sqlc.arg(username)
+  AND hashed_code = sqlc.arg(hashed_code)
+  AND used_at IS NULL RETURNING *;
@@ -1,18 +1,44 @@
 -- name: CreateTransfer :one
 INSERT INTO transfers (from_account_id,
                        to_account_id,
-                       amount)
-VALUES ($1, $2, $3) RETURNING *;
+                       amount,
+                       reversal_of)
+VALUES ($1, $2, $3, $4) RETURNING *;
 
 -- name: GetTransfer :one
 SELECT *
 FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT *
+FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY
+UPDATE;
+
+-- name: GetReversedAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint
+FROM transfers
+WHERE reversal_of = sqlc.arg(transfer_id)::bigint;
+
 -- name: ListTransfers :many
 SELECT *
 FROM transfers
 WHERE from_account_id = $1
    OR to_account_id = $2
 ORDER BY id LIMIT $3
-OFFSET $4;
\ No newline at end of file
+OFFSET $4;
+
+-- name: ListAccountTransfers :many
+SELECT *
+FROM transfers
+WHERE ((sqlc.arg(direction)::text <> 'incoming' AND from_account_id = sqlc.arg(account_id))
+    OR (sqlc.arg(direction) <> 'outgoing' AND to_account_id = sqlc.arg(account_id)))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+ORDER BY CASE WHEN sqlc.arg(sort_by)::text = 'amount' AND sqlc.arg(sort_desc)::bool THEN amount END DESC,
+         CASE WHEN sqlc.arg(sort_by) = 'amount' AND NOT sqlc.arg(sort_desc) THEN amount END,
+         CASE WHEN sqlc.arg(sort_desc) THEN id END DESC,
+         id
+LIMIT sqlc.arg(limit_count) OFFSET sqlc.arg(offset_count);
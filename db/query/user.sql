@@ -5,4 +5,59 @@ VALUES ($1, $2, $3, $4) RETURNING *;
 -- name: GetUser :one
 SELECT *
 FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = sqlc.arg(role)
+WHERE username = sqlc.arg(username) RETURNING *;
+
+-- name: GetUserByEmail :one
+SELECT *
+FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password     = sqlc.arg(hashed_password),
+    password_changed_at = now()
+WHERE username = sqlc.arg(username) RETURNING *;
+
+-- name: VerifyUserEmail :one
+UPDATE users
+SET is_email_verified = true
+WHERE username = sqlc.arg(username)
+  AND email = sqlc.arg(email) RETURNING *;
+
+-- name: UpdateUser :one
+UPDATE users
+SET full_name         = COALESCE(sqlc.narg(full_name), full_name),
+    email             = COALESCE(sqlc.narg(email), email),
+    is_email_verified = CASE
+                            WHEN sqlc.narg(email)::varchar IS NULL OR sqlc.narg(email)::varchar = email
+                                THEN is_email_verified
+                            ELSE false
+        END
+WHERE username = sqlc.arg(username) RETURNING *;
+
+-- name: RehashUserPassword :execrows
+UPDATE users
+SET hashed_password = sqlc.arg(new_hashed_password)
+WHERE username = sqlc.arg(username)
+  AND hashed_password = sqlc.arg(old_hashed_password);
+
+-- name: GetUserStatus :one
+SELECT status
+FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: CloseUser :one
+UPDATE users
+SET status            = 'closed',
+    closed_at         = now(),
+    full_name         = '',
+    email             = 'closed+' || username || '@invalid',
+    hashed_password   = '',
+    is_email_verified = false
+WHERE username = sqlc.arg(username)
+  AND status = 'active' RETURNING *;
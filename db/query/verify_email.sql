@@ -0,0 +1,16 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (username, email, hashed_secret_code, expires_at)
+VALUES ($1, $2, $3, $4) RETURNING *;
+
+-- name: UseVerifyEmail :one
+UPDATE verify_emails
+SET is_used = true
+WHERE id = sqlc.arg(id)
+  AND hashed_secret_code = sqlc.arg(hashed_secret_code)
+  AND is_used = false
+  AND expires_at > now() RETURNING *;
+
+-- name: DeleteUserVerifyEmails :exec
+DELETE
+FROM verify_emails
+WHERE username = $1;
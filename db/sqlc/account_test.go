@@ -10,10 +10,14 @@ import (
 )
 
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountWithBalance(t, randutil.Money())
+}
+
+func createRandomAccountWithBalance(t *testing.T, balance int64) Account {
 	user := createRandomUser(t)
 	arg := CreateAccountParams{
 		Owner:    user.Username,
-		Balance:  randutil.Money(),
+		Balance:  balance,
 		Currency: randutil.Currency(),
 	}
 
@@ -0,0 +1,175 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: api_key.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createApiKey = `-- name: CreateApiKey :one
+INSERT INTO api_keys (id, username, name, prefix, hashed_key, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, username, name, prefix, hashed_key, scopes, expires_at, revoked_at, created_at
+`
+
+type CreateApiKeyParams struct {
+	ID        uuid.UUID    `json:"id"`
+	Username  string       `json:"username"`
+	Name      string       `json:"name"`
+	Prefix    string       `json:"prefix"`
+	HashedKey string       `json:"hashed_key"`
+	Scopes    []string     `json:"scopes"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+}
+
+func (q *Queries) CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, createApiKey,
+		arg.ID,
+		arg.Username,
+		arg.Name,
+		arg.Prefix,
+		arg.HashedKey,
+		pq.Array(arg.Scopes),
+		arg.ExpiresAt,
+	)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedKey,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getApiKeyByPrefix = `-- name: GetApiKeyByPrefix :one
+SELECT api_keys.id, api_keys.username, api_keys.name, api_keys.prefix, api_keys.hashed_key, api_keys.scopes, api_keys.expires_at, api_keys.revoked_at, api_keys.created_at, users.role
+FROM api_keys
+         JOIN users ON users.username = api_keys.username
+WHERE api_keys.prefix = $1 LIMIT 1
+`
+
+type GetApiKeyByPrefixRow struct {
+	ID        uuid.UUID    `json:"id"`
+	Username  string       `json:"username"`
+	Name      string       `json:"name"`
+	Prefix    string       `json:"prefix"`
+	HashedKey string       `json:"hashed_key"`
+	Scopes    []string     `json:"scopes"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+	CreatedAt time.Time    `json:"created_at"`
+	Role      string       `json:"role"`
+}
+
+func (q *Queries) GetApiKeyByPrefix(ctx context.Context, prefix string) (GetApiKeyByPrefixRow, error) {
+	row := q.db.QueryRowContext(ctx, getApiKeyByPrefix, prefix)
+	var i GetApiKeyByPrefixRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedKey,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const listApiKeys = `-- name: ListApiKeys :many
+SELECT id, username, name, prefix, hashed_key, scopes, expires_at, revoked_at, created_at
+FROM api_keys
+WHERE username = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListApiKeys(ctx context.Context, username string) ([]ApiKey, error) {
+	rows, err := q.db.QueryContext(ctx, listApiKeys, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Name,
+			&i.Prefix,
+			&i.HashedKey,
+			pq.Array(&i.Scopes),
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeApiKey = `-- name: RevokeApiKey :one
+UPDATE api_keys
+SET revoked_at = now()
+WHERE id = $1
+  AND username = $2
+  AND revoked_at IS NULL RETURNING id, username, name, prefix, hashed_key, scopes, expires_at, revoked_at, created_at
+`
+
+type RevokeApiKeyParams struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+}
+
+func (q *Queries) RevokeApiKey(ctx context.Context, arg RevokeApiKeyParams) (ApiKey, error) {
+	row := q.db.QueryRowContext(ctx, revokeApiKey, arg.ID, arg.Username)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Name,
+		&i.Prefix,
+		&i.HashedKey,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const revokeUserApiKeys = `-- name: RevokeUserApiKeys :exec
+UPDATE api_keys
+SET revoked_at = now()
+WHERE username = $1
+  AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserApiKeys(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, revokeUserApiKeys, username)
+	return err
+}
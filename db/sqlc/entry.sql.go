@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createEntry = `-- name: CreateEntry :one
@@ -50,6 +51,70 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 	return i, err
 }
 
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT id, account_id, amount, created_at
+FROM entries
+WHERE account_id = $1
+  AND ($2::timestamptz IS NULL OR created_at >= $2)
+  AND ($3::timestamptz IS NULL OR created_at < $3)
+  AND ($4::text = ''
+    OR ($4 = 'incoming' AND amount > 0)
+    OR ($4 = 'outgoing' AND amount < 0))
+ORDER BY CASE WHEN $5::text = 'amount' AND $6::bool THEN amount END DESC,
+         CASE WHEN $5 = 'amount' AND NOT $6 THEN amount END,
+         CASE WHEN $6 THEN id END DESC,
+         id
+LIMIT $8 OFFSET $7
+`
+
+type ListAccountEntriesParams struct {
+	AccountID   int64        `json:"account_id"`
+	FromTime    sql.NullTime `json:"from_time"`
+	ToTime      sql.NullTime `json:"to_time"`
+	Direction   string       `json:"direction"`
+	SortBy      string       `json:"sort_by"`
+	SortDesc    bool         `json:"sort_desc"`
+	OffsetCount int32        `json:"offset_count"`
+	LimitCount  int32        `json:"limit_count"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Direction,
+		arg.SortBy,
+		arg.SortDesc,
+		arg.OffsetCount,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Entry
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntries = `-- name: ListEntries :many
 SELECT id, account_id, amount, created_at
 FROM entries
@@ -67,3 +67,33 @@ func TestListEntries(t *testing.T) {
 		require.Equal(t, arg.AccountID, entry.AccountID)
 	}
 }
+
+func TestListAccountEntries(t *testing.T) {
+	account := createRandomAccount(t)
+	for i := 0; i < 5; i++ {
+		createRandomEntry(t, account)
+	}
+	_, err := _testQueries.CreateEntry(context.Background(), CreateEntryParams{
+		AccountID: account.ID,
+		Amount:    -10,
+	})
+	require.NoError(t, err)
+
+	arg := ListAccountEntriesParams{
+		AccountID:  account.ID,
+		Direction:  "outgoing",
+		LimitCount: 10,
+	}
+
+	entries, err := _testQueries.ListAccountEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, int64(-10), entries[0].Amount)
+
+	arg.Direction = ""
+	arg.SortDesc = true
+	entries, err = _testQueries.ListAccountEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+	require.Greater(t, entries[0].ID, entries[5].ID)
+}
@@ -0,0 +1,115 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: external_movement.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createExternalMovement = `-- name: CreateExternalMovement :one
+INSERT INTO external_movements (account_id, entry_id, amount, source, reference, channel)
+VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, account_id, entry_id, amount, source, reference, channel, created_at
+`
+
+type CreateExternalMovementParams struct {
+	AccountID int64  `json:"account_id"`
+	EntryID   int64  `json:"entry_id"`
+	Amount    int64  `json:"amount"`
+	Source    string `json:"source"`
+	Reference string `json:"reference"`
+	Channel   string `json:"channel"`
+}
+
+func (q *Queries) CreateExternalMovement(ctx context.Context, arg CreateExternalMovementParams) (ExternalMovement, error) {
+	row := q.db.QueryRowContext(ctx, createExternalMovement,
+		arg.AccountID,
+		arg.EntryID,
+		arg.Amount,
+		arg.Source,
+		arg.Reference,
+		arg.Channel,
+	)
+	var i ExternalMovement
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.EntryID,
+		&i.Amount,
+		&i.Source,
+		&i.Reference,
+		&i.Channel,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getExternalMovement = `-- name: GetExternalMovement :one
+SELECT id, account_id, entry_id, amount, source, reference, channel, created_at
+FROM external_movements
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetExternalMovement(ctx context.Context, id int64) (ExternalMovement, error) {
+	row := q.db.QueryRowContext(ctx, getExternalMovement, id)
+	var i ExternalMovement
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.EntryID,
+		&i.Amount,
+		&i.Source,
+		&i.Reference,
+		&i.Channel,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listExternalMovements = `-- name: ListExternalMovements :many
+SELECT id, account_id, entry_id, amount, source, reference, channel, created_at
+FROM external_movements
+WHERE account_id = $1
+ORDER BY id LIMIT $2
+OFFSET $3
+`
+
+type ListExternalMovementsParams struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListExternalMovements(ctx context.Context, arg ListExternalMovementsParams) ([]ExternalMovement, error) {
+	rows, err := q.db.QueryContext(ctx, listExternalMovements, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExternalMovement
+	for rows.Next() {
+		var i ExternalMovement
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.EntryID,
+			&i.Amount,
+			&i.Source,
+			&i.Reference,
+			&i.Channel,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
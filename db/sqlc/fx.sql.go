@@ -0,0 +1,195 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: fx.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createFxQuote = `-- name: CreateFxQuote :one
+INSERT INTO fx_quotes (id, username, from_currency, to_currency, rate, from_amount, to_amount, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, username, from_currency, to_currency, rate, from_amount, to_amount, transfer_id, expires_at, created_at
+`
+
+type CreateFxQuoteParams struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Rate         string    `json:"rate"`
+	FromAmount   int64     `json:"from_amount"`
+	ToAmount     int64     `json:"to_amount"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateFxQuote(ctx context.Context, arg CreateFxQuoteParams) (FxQuote, error) {
+	row := q.db.QueryRowContext(ctx, createFxQuote,
+		arg.ID,
+		arg.Username,
+		arg.FromCurrency,
+		arg.ToCurrency,
+		arg.Rate,
+		arg.FromAmount,
+		arg.ToAmount,
+		arg.ExpiresAt,
+	)
+	var i FxQuote
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.Rate,
+		&i.FromAmount,
+		&i.ToAmount,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createFxRate = `-- name: CreateFxRate :one
+INSERT INTO fx_rates (base_currency, quote_currency, rate, valid_from)
+VALUES ($1, $2, $3, $4) RETURNING id, base_currency, quote_currency, rate, valid_from, created_at
+`
+
+type CreateFxRateParams struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          string    `json:"rate"`
+	ValidFrom     time.Time `json:"valid_from"`
+}
+
+func (q *Queries) CreateFxRate(ctx context.Context, arg CreateFxRateParams) (FxRate, error) {
+	row := q.db.QueryRowContext(ctx, createFxRate,
+		arg.BaseCurrency,
+		arg.QuoteCurrency,
+		arg.Rate,
+		arg.ValidFrom,
+	)
+	var i FxRate
+	err := row.Scan(
+		&i.ID,
+		&i.BaseCurrency,
+		&i.QuoteCurrency,
+		&i.Rate,
+		&i.ValidFrom,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCurrentFxRate = `-- name: GetCurrentFxRate :one
+SELECT id, base_currency, quote_currency, rate, valid_from, created_at
+FROM fx_rates
+WHERE base_currency = $1
+  AND quote_currency = $2
+  AND valid_from <= now()
+ORDER BY valid_from DESC LIMIT 1
+`
+
+type GetCurrentFxRateParams struct {
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+}
+
+func (q *Queries) GetCurrentFxRate(ctx context.Context, arg GetCurrentFxRateParams) (FxRate, error) {
+	row := q.db.QueryRowContext(ctx, getCurrentFxRate, arg.BaseCurrency, arg.QuoteCurrency)
+	var i FxRate
+	err := row.Scan(
+		&i.ID,
+		&i.BaseCurrency,
+		&i.QuoteCurrency,
+		&i.Rate,
+		&i.ValidFrom,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getFxQuote = `-- name: GetFxQuote :one
+SELECT id, username, from_currency, to_currency, rate, from_amount, to_amount, transfer_id, expires_at, created_at
+FROM fx_quotes
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetFxQuote(ctx context.Context, id uuid.UUID) (FxQuote, error) {
+	row := q.db.QueryRowContext(ctx, getFxQuote, id)
+	var i FxQuote
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.Rate,
+		&i.FromAmount,
+		&i.ToAmount,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getFxQuoteForUpdate = `-- name: GetFxQuoteForUpdate :one
+SELECT id, username, from_currency, to_currency, rate, from_amount, to_amount, transfer_id, expires_at, created_at
+FROM fx_quotes
+WHERE id = $1 LIMIT 1
+FOR NO KEY
+UPDATE
+`
+
+func (q *Queries) GetFxQuoteForUpdate(ctx context.Context, id uuid.UUID) (FxQuote, error) {
+	row := q.db.QueryRowContext(ctx, getFxQuoteForUpdate, id)
+	var i FxQuote
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.Rate,
+		&i.FromAmount,
+		&i.ToAmount,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useFxQuote = `-- name: UseFxQuote :one
+UPDATE fx_quotes
+SET transfer_id = $1
+WHERE id = $2 RETURNING id, username, from_currency, to_currency, rate, from_amount, to_amount, transfer_id, expires_at, created_at
+`
+
+type UseFxQuoteParams struct {
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ID         uuid.UUID     `json:"id"`
+}
+
+func (q *Queries) UseFxQuote(ctx context.Context, arg UseFxQuoteParams) (FxQuote, error) {
+	row := q.db.QueryRowContext(ctx, useFxQuote, arg.TransferID, arg.ID)
+	var i FxQuote
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.Rate,
+		&i.FromAmount,
+		&i.ToAmount,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
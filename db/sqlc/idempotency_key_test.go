@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/util/randutil"
+	"testing"
+	"time"
+)
+
+func createRandomIdempotencyKey(t *testing.T, expiresAt time.Time) IdempotencyKey {
+	user := createRandomUser(t)
+	arg := CreateIdempotencyKeyParams{
+		Username:       user.Username,
+		IdempotencyKey: randutil.StringWithQuantity(16),
+		RequestHash:    randutil.StringWithQuantity(64),
+		ExpiresAt:      expiresAt,
+	}
+
+	key, err := _testQueries.CreateIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, key.Username)
+	require.Equal(t, arg.IdempotencyKey, key.IdempotencyKey)
+	require.Equal(t, arg.RequestHash, key.RequestHash)
+	require.False(t, key.ResponseStatus.Valid)
+	require.Empty(t, key.ResponseBody)
+	require.WithinDuration(t, arg.ExpiresAt, key.ExpiresAt, time.Second)
+
+	return key
+}
+
+func TestCreateIdempotencyKey(t *testing.T) {
+	createRandomIdempotencyKey(t, time.Now().Add(time.Hour))
+}
+
+func TestCreateIdempotencyKeyConflict(t *testing.T) {
+	key1 := createRandomIdempotencyKey(t, time.Now().Add(time.Hour))
+
+	_, err := _testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+		Username:       key1.Username,
+		IdempotencyKey: key1.IdempotencyKey,
+		RequestHash:    randutil.StringWithQuantity(64),
+		ExpiresAt:      time.Now().Add(time.Hour),
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestCreateIdempotencyKeyReclaimExpired(t *testing.T) {
+	key1 := createRandomIdempotencyKey(t, time.Now().Add(-time.Minute))
+
+	arg := CreateIdempotencyKeyParams{
+		Username:       key1.Username,
+		IdempotencyKey: key1.IdempotencyKey,
+		RequestHash:    randutil.StringWithQuantity(64),
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+	key2, err := _testQueries.CreateIdempotencyKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.RequestHash, key2.RequestHash)
+	require.WithinDuration(t, arg.ExpiresAt, key2.ExpiresAt, time.Second)
+}
+
+func TestUpdateIdempotencyKeyResponse(t *testing.T) {
+	key1 := createRandomIdempotencyKey(t, time.Now().Add(time.Hour))
+
+	arg := UpdateIdempotencyKeyResponseParams{
+		Username:       key1.Username,
+		IdempotencyKey: key1.IdempotencyKey,
+		ResponseStatus: sql.NullInt32{Int32: 201, Valid: true},
+		ResponseBody:   []byte(`{"ok":true}`),
+	}
+	key2, err := _testQueries.UpdateIdempotencyKeyResponse(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ResponseStatus, key2.ResponseStatus)
+	require.Equal(t, arg.ResponseBody, key2.ResponseBody)
+
+	key3, err := _testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username:       key1.Username,
+		IdempotencyKey: key1.IdempotencyKey,
+	})
+	require.NoError(t, err)
+	require.Equal(t, key2.ResponseBody, key3.ResponseBody)
+}
+
+func TestDeleteIdempotencyKey(t *testing.T) {
+	key1 := createRandomIdempotencyKey(t, time.Now().Add(time.Hour))
+
+	err := _testQueries.DeleteIdempotencyKey(context.Background(), DeleteIdempotencyKeyParams{
+		Username:       key1.Username,
+		IdempotencyKey: key1.IdempotencyKey,
+	})
+	require.NoError(t, err)
+
+	_, err = _testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username:       key1.Username,
+		IdempotencyKey: key1.IdempotencyKey,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
@@ -0,0 +1,113 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: login_throttle.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const deleteLoginThrottle = `-- name: DeleteLoginThrottle :execrows
+DELETE
+FROM login_throttles
+WHERE scope = $1
+  AND key = $2
+`
+
+type DeleteLoginThrottleParams struct {
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+}
+
+func (q *Queries) DeleteLoginThrottle(ctx context.Context, arg DeleteLoginThrottleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteLoginThrottle, arg.Scope, arg.Key)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getLoginThrottle = `-- name: GetLoginThrottle :one
+SELECT scope, key, failures, locked_until, updated_at
+FROM login_throttles
+WHERE scope = $1
+  AND key = $2 LIMIT 1
+`
+
+type GetLoginThrottleParams struct {
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+}
+
+func (q *Queries) GetLoginThrottle(ctx context.Context, arg GetLoginThrottleParams) (LoginThrottle, error) {
+	row := q.db.QueryRowContext(ctx, getLoginThrottle, arg.Scope, arg.Key)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.Scope,
+		&i.Key,
+		&i.Failures,
+		&i.LockedUntil,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const lockLogin = `-- name: LockLogin :one
+UPDATE login_throttles
+SET locked_until = $1
+WHERE scope = $2
+  AND key = $3 RETURNING scope, key, failures, locked_until, updated_at
+`
+
+type LockLoginParams struct {
+	LockedUntil sql.NullTime `json:"locked_until"`
+	Scope       string       `json:"scope"`
+	Key         string       `json:"key"`
+}
+
+func (q *Queries) LockLogin(ctx context.Context, arg LockLoginParams) (LoginThrottle, error) {
+	row := q.db.QueryRowContext(ctx, lockLogin, arg.LockedUntil, arg.Scope, arg.Key)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.Scope,
+		&i.Key,
+		&i.Failures,
+		&i.LockedUntil,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const recordLoginFailure = `-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (scope, key, failures)
+VALUES ($1, $2, 1) ON CONFLICT (scope, key) DO
+UPDATE
+SET failures   = CASE
+                     WHEN login_throttles.updated_at < $3::timestamptz THEN 1
+                     ELSE login_throttles.failures + 1
+    END,
+    updated_at = now() RETURNING scope, key, failures, locked_until, updated_at
+`
+
+type RecordLoginFailureParams struct {
+	Scope       string    `json:"scope"`
+	Key         string    `json:"key"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+func (q *Queries) RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginThrottle, error) {
+	row := q.db.QueryRowContext(ctx, recordLoginFailure, arg.Scope, arg.Key, arg.WindowStart)
+	var i LoginThrottle
+	err := row.Scan(
+		&i.Scope,
+		&i.Key,
+		&i.Failures,
+		&i.LockedUntil,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -5,6 +5,7 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +17,23 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// how far below zero the balance is allowed to go
+	OverdraftLimit int64 `json:"overdraft_limit"`
+}
+
+type ApiKey struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Name     string    `json:"name"`
+	// public part of the key used to look it up
+	Prefix string `json:"prefix"`
+	// sha256 of the whole key, the key itself is only shown once
+	HashedKey string `json:"hashed_key"`
+	// empty means the key grants every scope of its owner
+	Scopes    []string     `json:"scopes"`
+	ExpiresAt sql.NullTime `json:"expires_at"`
+	RevokedAt sql.NullTime `json:"revoked_at"`
+	CreatedAt time.Time    `json:"created_at"`
 }
 
 type Entry struct {
@@ -26,6 +44,119 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type ExternalMovement struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	EntryID   int64 `json:"entry_id"`
+	// positive for deposits, negative for withdrawals
+	Amount int64 `json:"amount"`
+	// where the money comes from or goes to, e.g. an external bank account
+	Source    string    `json:"source"`
+	Reference string    `json:"reference"`
+	Channel   string    `json:"channel"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FxQuote struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Rate         string    `json:"rate"`
+	FromAmount   int64     `json:"from_amount"`
+	ToAmount     int64     `json:"to_amount"`
+	// set once the quote has been used by a transfer
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type FxRate struct {
+	ID            int64  `json:"id"`
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	// amount of quote currency bought by one unit of base currency, must be positive
+	Rate      string    `json:"rate"`
+	ValidFrom time.Time `json:"valid_from"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Username       string `json:"username"`
+	IdempotencyKey string `json:"idempotency_key"`
+	RequestHash    string `json:"request_hash"`
+	// null while the original request is in progress
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	ResponseBody   []byte        `json:"response_body"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+type LoginThrottle struct {
+	// username or ip
+	Scope string `json:"scope"`
+	Key   string `json:"key"`
+	// consecutive failed logins, reset after a quiet window or a successful login
+	Failures    int32        `json:"failures"`
+	LockedUntil sql.NullTime `json:"locked_until"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type PasswordResetToken struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the token, the token itself is only sent to the user
+	HashedToken string    `json:"hashed_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	// set once the token reset the password, or when another reset made it obsolete
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type RecoveryCode struct {
+	ID         int64        `json:"id"`
+	Username   string       `json:"username"`
+	HashedCode string       `json:"hashed_code"`
+	UsedAt     sql.NullTime `json:"used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type RevokedToken struct {
+	// id of the revoked access token
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// must be positive
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	// @daily, @weekly, @monthly or @every <duration>
+	Schedule  string    `json:"schedule"`
+	NextRunAt time.Time `json:"next_run_at"`
+	// active, paused or cancelled
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	// first run, @monthly schedules keep its day of month
+	StartAt time.Time `json:"start_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64         `json:"id"`
+	ScheduledTransferID int64         `json:"scheduled_transfer_id"`
+	TransferID          sql.NullInt64 `json:"transfer_id"`
+	// succeeded or failed
+	Status       string    `json:"status"`
+	ErrorMessage string    `json:"error_message"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 type Session struct {
 	ID           uuid.UUID `json:"id"`
 	Username     string    `json:"username"`
@@ -35,6 +166,24 @@ type Session struct {
 	IsBlocked    bool      `json:"is_blocked"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	CreatedAt    time.Time `json:"created_at"`
+	// id of the login session every rotated session descends from
+	FamilyID uuid.UUID `json:"family_id"`
+	// session issued when this refresh token was rotated
+	ReplacedBy uuid.NullUUID `json:"replaced_by"`
+	// access token issued together with the refresh token of the session
+	AccessTokenID        uuid.NullUUID `json:"access_token_id"`
+	AccessTokenExpiresAt sql.NullTime  `json:"access_token_expires_at"`
+}
+
+type TotpSecret struct {
+	Username string `json:"username"`
+	// base32 encoded shared secret, it has to be readable to compute the codes
+	Secret string `json:"secret"`
+	// time step of the last accepted code, older or equal steps are replays
+	LastUsedStep int64 `json:"last_used_step"`
+	// null until the user proved the authenticator app works, login only asks for a code once set
+	ConfirmedAt sql.NullTime `json:"confirmed_at"`
+	CreatedAt   time.Time    `json:"created_at"`
 }
 
 type Transfer struct {
@@ -44,6 +193,8 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// the transfer compensated by this one
+	ReversalOf sql.NullInt64 `json:"reversal_of"`
 }
 
 type User struct {
@@ -53,4 +204,21 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	// active or closed, a closed user is anonymized and can't log in
+	Status   string       `json:"status"`
+	ClosedAt sql.NullTime `json:"closed_at"`
+}
+
+type VerifyEmail struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// address the code was sent to, the user is only verified if it still has this email
+	Email string `json:"email"`
+	// sha256 of the secret code, the code itself is only sent to the user
+	HashedSecretCode string    `json:"hashed_secret_code"`
+	IsUsed           bool      `json:"is_used"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	CreatedAt        time.Time `json:"created_at"`
 }
@@ -0,0 +1,97 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: password_reset_token.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createPasswordResetToken = `-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (username, hashed_token, expires_at)
+VALUES ($1, $2, $3) RETURNING id, username, hashed_token, expires_at, used_at, created_at
+`
+
+type CreatePasswordResetTokenParams struct {
+	Username    string    `json:"username"`
+	HashedToken string    `json:"hashed_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, createPasswordResetToken, arg.Username, arg.HashedToken, arg.ExpiresAt)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedToken,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserByPasswordResetToken = `-- name: GetUserByPasswordResetToken :one
+SELECT users.username, users.hashed_password, users.full_name, users.email, users.password_changed_at, users.created_at, users.role, users.is_email_verified, users.status, users.closed_at
+FROM password_reset_tokens
+         JOIN users ON users.username = password_reset_tokens.username
+WHERE password_reset_tokens.hashed_token = $1
+  AND password_reset_tokens.used_at IS NULL
+  AND password_reset_tokens.expires_at > now() LIMIT 1
+`
+
+func (q *Queries) GetUserByPasswordResetToken(ctx context.Context, hashedToken string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByPasswordResetToken, hashedToken)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.IsEmailVerified,
+		&i.Status,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const invalidatePasswordResetTokens = `-- name: InvalidatePasswordResetTokens :exec
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE username = $1
+  AND used_at IS NULL
+`
+
+func (q *Queries) InvalidatePasswordResetTokens(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, invalidatePasswordResetTokens, username)
+	return err
+}
+
+const usePasswordResetToken = `-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens
+SET used_at = now()
+WHERE hashed_token = $1
+  AND used_at IS NULL
+  AND expires_at > now() RETURNING id, username, hashed_token, expires_at, used_at, created_at
+`
+
+func (q *Queries) UsePasswordResetToken(ctx context.Context, hashedToken string) (PasswordResetToken, error) {
+	row := q.db.QueryRowContext(ctx, usePasswordResetToken, hashedToken)
+	var i PasswordResetToken
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedToken,
+		&i.ExpiresAt,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
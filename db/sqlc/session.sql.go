@@ -7,24 +7,195 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const blockOtherUserSessions = `-- name: BlockOtherUserSessions :many
+UPDATE sessions
+SET is_blocked = true
+WHERE username = $1
+  AND is_blocked = false
+  AND access_token_id IS DISTINCT FROM $2::uuid RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by, access_token_id, access_token_expires_at
+`
+
+type BlockOtherUserSessionsParams struct {
+	Username             string    `json:"username"`
+	CurrentAccessTokenID uuid.UUID `json:"current_access_token_id"`
+}
+
+func (q *Queries) BlockOtherUserSessions(ctx context.Context, arg BlockOtherUserSessionsParams) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, blockOtherUserSessions, arg.Username, arg.CurrentAccessTokenID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.FamilyID,
+			&i.ReplacedBy,
+			&i.AccessTokenID,
+			&i.AccessTokenExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const blockSession = `-- name: BlockSession :one
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1
+  AND username = $2 RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by, access_token_id, access_token_expires_at
+`
+
+type BlockSessionParams struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+}
+
+func (q *Queries) BlockSession(ctx context.Context, arg BlockSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, blockSession, arg.ID, arg.Username)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.AccessTokenID,
+		&i.AccessTokenExpiresAt,
+	)
+	return i, err
+}
+
+const blockSessionFamily = `-- name: BlockSessionFamily :many
+UPDATE sessions
+SET is_blocked = true
+WHERE family_id = $1 RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by, access_token_id, access_token_expires_at
+`
+
+func (q *Queries) BlockSessionFamily(ctx context.Context, familyID uuid.UUID) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, blockSessionFamily, familyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.FamilyID,
+			&i.ReplacedBy,
+			&i.AccessTokenID,
+			&i.AccessTokenExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const blockUserSessions = `-- name: BlockUserSessions :many
+UPDATE sessions
+SET is_blocked = true
+WHERE username = $1
+  AND is_blocked = false RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by, access_token_id, access_token_expires_at
+`
+
+func (q *Queries) BlockUserSessions(ctx context.Context, username string) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, blockUserSessions, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.FamilyID,
+			&i.ReplacedBy,
+			&i.AccessTokenID,
+			&i.AccessTokenExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createSession = `-- name: CreateSession :one
-INSERT INTO sessions (id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+INSERT INTO sessions (id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, family_id,
+                      access_token_id, access_token_expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by, access_token_id, access_token_expires_at
 `
 
 type CreateSessionParams struct {
-	ID           uuid.UUID `json:"id"`
-	Username     string    `json:"username"`
-	RefreshToken string    `json:"refresh_token"`
-	UserAgent    string    `json:"user_agent"`
-	ClientIp     string    `json:"client_ip"`
-	IsBlocked    bool      `json:"is_blocked"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	ID                   uuid.UUID     `json:"id"`
+	Username             string        `json:"username"`
+	RefreshToken         string        `json:"refresh_token"`
+	UserAgent            string        `json:"user_agent"`
+	ClientIp             string        `json:"client_ip"`
+	IsBlocked            bool          `json:"is_blocked"`
+	ExpiresAt            time.Time     `json:"expires_at"`
+	FamilyID             uuid.UUID     `json:"family_id"`
+	AccessTokenID        uuid.NullUUID `json:"access_token_id"`
+	AccessTokenExpiresAt sql.NullTime  `json:"access_token_expires_at"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
@@ -36,6 +207,9 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		arg.ClientIp,
 		arg.IsBlocked,
 		arg.ExpiresAt,
+		arg.FamilyID,
+		arg.AccessTokenID,
+		arg.AccessTokenExpiresAt,
 	)
 	var i Session
 	err := row.Scan(
@@ -47,12 +221,16 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.AccessTokenID,
+		&i.AccessTokenExpiresAt,
 	)
 	return i, err
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by, access_token_id, access_token_expires_at
 FROM sessions
 WHERE id = $1 LIMIT 1
 `
@@ -69,6 +247,115 @@ func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.AccessTokenID,
+		&i.AccessTokenExpiresAt,
+	)
+	return i, err
+}
+
+const getSessionByAccessToken = `-- name: GetSessionByAccessToken :one
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by, access_token_id, access_token_expires_at
+FROM sessions
+WHERE access_token_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetSessionByAccessToken(ctx context.Context, accessTokenID uuid.NullUUID) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSessionByAccessToken, accessTokenID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.AccessTokenID,
+		&i.AccessTokenExpiresAt,
+	)
+	return i, err
+}
+
+const listActiveSessions = `-- name: ListActiveSessions :many
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by, access_token_id, access_token_expires_at
+FROM sessions
+WHERE username = $1
+  AND is_blocked = false
+  AND replaced_by IS NULL
+  AND expires_at > now()
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListActiveSessions(ctx context.Context, username string) ([]Session, error) {
+	rows, err := q.db.QueryContext(ctx, listActiveSessions, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.FamilyID,
+			&i.ReplacedBy,
+			&i.AccessTokenID,
+			&i.AccessTokenExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rotateSession = `-- name: RotateSession :one
+UPDATE sessions
+SET replaced_by = $1::uuid
+WHERE id = $2
+  AND replaced_by IS NULL
+  AND is_blocked = false RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, family_id, replaced_by, access_token_id, access_token_expires_at
+`
+
+type RotateSessionParams struct {
+	ReplacedBy uuid.UUID `json:"replaced_by"`
+	ID         uuid.UUID `json:"id"`
+}
+
+func (q *Queries) RotateSession(ctx context.Context, arg RotateSessionParams) (Session, error) {
+	row := q.db.QueryRowContext(ctx, rotateSession, arg.ReplacedBy, arg.ID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.FamilyID,
+		&i.ReplacedBy,
+		&i.AccessTokenID,
+		&i.AccessTokenExpiresAt,
 	)
 	return i, err
 }
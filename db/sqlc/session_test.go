@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/util/randutil"
+	"testing"
+	"time"
+)
+
+func createRandomSession(t *testing.T, user User) Session {
+	id := uuid.New()
+	arg := CreateSessionParams{
+		ID:           id,
+		Username:     user.Username,
+		RefreshToken: randutil.StringWithQuantity(32),
+		UserAgent:    "test-agent",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(time.Hour),
+		FamilyID:     id,
+	}
+
+	session, err := _testQueries.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, session.ID)
+	require.False(t, session.IsBlocked)
+
+	return session
+}
+
+func TestBlockSession(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+	session2 := createRandomSession(t, user)
+
+	blocked, err := _testQueries.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session1.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	// a session can only be blocked by its owner
+	other := createRandomUser(t)
+	_, err = _testQueries.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session2.ID,
+		Username: other.Username,
+	})
+	require.Error(t, err)
+
+	sessions, err := _testQueries.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, session2.ID, sessions[0].ID)
+
+	blockedSessions, err := _testQueries.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, blockedSessions, 1)
+
+	sessions, err = _testQueries.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	store := NewStore(_testDB)
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+
+	newSession := func() CreateSessionParams {
+		return CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     user.Username,
+			RefreshToken: randutil.StringWithQuantity(32),
+			UserAgent:    "test-agent",
+			ClientIp:     "127.0.0.1",
+			ExpiresAt:    time.Now().Add(time.Hour),
+		}
+	}
+
+	session2, err := store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		OldSessionID: session1.ID,
+		NewSession:   newSession(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, session1.FamilyID, session2.FamilyID)
+	require.False(t, session2.ReplacedBy.Valid)
+
+	rotated, err := store.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, rotated.ReplacedBy.Valid)
+	require.Equal(t, session2.ID, rotated.ReplacedBy.UUID)
+
+	// the old refresh token can't be rotated a second time
+	_, err = store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		OldSessionID: session1.ID,
+		NewSession:   newSession(),
+	})
+	require.ErrorIs(t, err, ErrSessionReused)
+
+	family, err := store.BlockSessionFamily(context.Background(), session1.FamilyID)
+	require.NoError(t, err)
+	require.Len(t, family, 2)
+
+	_, err = store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		OldSessionID: session2.ID,
+		NewSession:   newSession(),
+	})
+	require.ErrorIs(t, err, ErrSessionReused)
+}
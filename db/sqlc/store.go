@@ -3,11 +3,29 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 )
 
+// ErrInsufficientFunds is returned when a transfer would take the source account below its overdraft limit
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
 type Store interface {
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	DepositTx(ctx context.Context, arg ExternalMovementTxParams) (ExternalMovementTxResult, error)
+	WithdrawTx(ctx context.Context, arg ExternalMovementTxParams) (ExternalMovementTxResult, error)
+	RunScheduledTransferTx(ctx context.Context) (ScheduledTransferRun, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error)
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error)
+	ConfirmTotpTx(ctx context.Context, arg ConfirmTotpTxParams) (TotpSecret, error)
+	ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (PasswordTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (PasswordTxResult, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	CloseUserTx(ctx context.Context, username string) (CloseUserTxResult, error)
+	QuoteFx(ctx context.Context, arg QuoteFxParams) (FxQuote, error)
+	FxTransferTx(ctx context.Context, arg FxTransferTxParams) (TransferTxResult, error)
 	Querier
 }
 
@@ -29,7 +47,7 @@ func NewStore(db *sql.DB) *SQLStore {
 func (s *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) error) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil
+		return err
 	}
 
 	q := New(tx)
@@ -49,9 +67,10 @@ func (s *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) error)
 
 // TransferTxParams contains the input parameters of the transfer transaction
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	ReversalOf    sql.NullInt64 `json:"reversal_of"`
 }
 
 // TransferTxResult is result of the transfer transaction
@@ -65,45 +84,72 @@ type TransferTxResult struct {
 
 // TransferTx performs a money transfer form account to the other
 // It creates a transfer record, add account entries, and update accounts balance with a single database transaction
+// The transaction is rolled back with ErrInsufficientFunds if the source account ends up below its overdraft limit,
+// or with ErrAccountClosed if the destination account belongs to a closed user
 func (s *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	err := s.execTx(ctx, func(q *Queries) error {
 		var err error
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+	return result, err
+}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+// transfer moves money between two accounts using the queries of an already opened transaction
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	return moveMoney(ctx, q, arg, arg.Amount)
+}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
+// moveMoney debits arg.Amount from the source account and credits creditAmount to the destination account,
+// both amounts only differ for a transfer converting between currencies
+func moveMoney(ctx context.Context, q *Queries, arg TransferTxParams, creditAmount int64) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		ReversalOf:    arg.ReversalOf,
+	})
+	if err != nil {
+		return result, err
+	}
 
-		return nil
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -arg.Amount,
 	})
+	if err != nil {
+		return result, err
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount:    creditAmount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, creditAmount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, creditAmount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// the source row is locked by the update above, so the new balance can't change until we commit
+	if result.FromAccount.Balance < -result.FromAccount.OverdraftLimit {
+		return result, ErrInsufficientFunds
+	}
 
+	err = checkOwnerActive(ctx, q, result.ToAccount)
 	return result, err
 }
 
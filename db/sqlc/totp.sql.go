@@ -0,0 +1,168 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: totp.sql
+
+package db
+
+import (
+	"context"
+)
+
+const confirmTotpSecret = `-- name: ConfirmTotpSecret :one
+UPDATE totp_secrets
+SET confirmed_at   = now(),
+    last_used_step = $1
+WHERE username = $2
+  AND confirmed_at IS NULL RETURNING username, secret, last_used_step, confirmed_at, created_at
+`
+
+type ConfirmTotpSecretParams struct {
+	Step     int64  `json:"step"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) ConfirmTotpSecret(ctx context.Context, arg ConfirmTotpSecretParams) (TotpSecret, error) {
+	row := q.db.QueryRowContext(ctx, confirmTotpSecret, arg.Step, arg.Username)
+	var i TotpSecret
+	err := row.Scan(
+		&i.Username,
+		&i.Secret,
+		&i.LastUsedStep,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createRecoveryCode = `-- name: CreateRecoveryCode :one
+INSERT INTO recovery_codes (username, hashed_code)
+VALUES ($1, $2) RETURNING id, username, hashed_code, used_at, created_at
+`
+
+type CreateRecoveryCodeParams struct {
+	Username   string `json:"username"`
+	HashedCode string `json:"hashed_code"`
+}
+
+func (q *Queries) CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, createRecoveryCode, arg.Username, arg.HashedCode)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedCode,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE
+FROM recovery_codes
+WHERE username = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, deleteRecoveryCodes, username)
+	return err
+}
+
+const getTotpSecret = `-- name: GetTotpSecret :one
+SELECT username, secret, last_used_step, confirmed_at, created_at
+FROM totp_secrets
+WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetTotpSecret(ctx context.Context, username string) (TotpSecret, error) {
+	row := q.db.QueryRowContext(ctx, getTotpSecret, username)
+	var i TotpSecret
+	err := row.Scan(
+		&i.Username,
+		&i.Secret,
+		&i.LastUsedStep,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertTotpSecret = `-- name: UpsertTotpSecret :one
+INSERT INTO totp_secrets (username, secret)
+VALUES ($1, $2) ON CONFLICT (username) DO
+UPDATE
+SET secret         = excluded.secret,
+    last_used_step = 0,
+    created_at     = now()
+WHERE totp_secrets.confirmed_at IS NULL RETURNING username, secret, last_used_step, confirmed_at, created_at
+`
+
+type UpsertTotpSecretParams struct {
+	Username string `json:"username"`
+	Secret   string `json:"secret"`
+}
+
+func (q *Queries) UpsertTotpSecret(ctx context.Context, arg UpsertTotpSecretParams) (TotpSecret, error) {
+	row := q.db.QueryRowContext(ctx, upsertTotpSecret, arg.Username, arg.Secret)
+	var i TotpSecret
+	err := row.Scan(
+		&i.Username,
+		&i.Secret,
+		&i.LastUsedStep,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :one
+UPDATE recovery_codes
+SET used_at = now()
+WHERE username = $1
+  AND hashed_code = $2
+  AND used_at IS NULL RETURNING id, username, hashed_code, used_at, created_at
+`
+
+type UseRecoveryCodeParams struct {
+	Username   string `json:"username"`
+	HashedCode string `json:"hashed_code"`
+}
+
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error) {
+	row := q.db.QueryRowContext(ctx, useRecoveryCode, arg.Username, arg.HashedCode)
+	var i RecoveryCode
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.HashedCode,
+		&i.UsedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const useTotpStep = `-- name: UseTotpStep :one
+UPDATE totp_secrets
+SET last_used_step = $1
+WHERE username = $2
+  AND last_used_step < $1 RETURNING username, secret, last_used_step, confirmed_at, created_at
+`
+
+type UseTotpStepParams struct {
+	Step     int64  `json:"step"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) UseTotpStep(ctx context.Context, arg UseTotpStepParams) (TotpSecret, error) {
+	row := q.db.QueryRowContext(ctx, useTotpStep, arg.Step, arg.Username)
+	var i TotpSecret
+	err := row.Scan(
+		&i.Username,
+		&i.Secret,
+		&i.LastUsedStep,
+		&i.ConfirmedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"github.com/thehaung/simplebank/util/randutil"
 	"testing"
 	"time"
@@ -77,3 +78,38 @@ func TestListTransfer(t *testing.T) {
 		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
 	}
 }
+
+func TestListAccountTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	for i := 0; i < 5; i++ {
+		createRandomTransfer(t, account1, account2)
+		createRandomTransfer(t, account2, account1)
+	}
+
+	arg := ListAccountTransfersParams{
+		AccountID:  account1.ID,
+		Direction:  "outgoing",
+		SortBy:     "amount",
+		SortDesc:   true,
+		LimitCount: 10,
+	}
+
+	transfers, err := _testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, transfers, 5)
+
+	for i, transfer := range transfers {
+		require.Equal(t, account1.ID, transfer.FromAccountID)
+		if i > 0 {
+			require.LessOrEqual(t, transfer.Amount, transfers[i-1].Amount)
+		}
+	}
+
+	arg.Direction = ""
+	arg.FromTime = sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+	transfers, err = _testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
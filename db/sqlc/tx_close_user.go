@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// User statuses, they must match the users_status_check constraint
+const (
+	UserActive = "active"
+	UserClosed = "closed"
+)
+
+var ErrNonZeroBalance = errors.New("every account must have a zero balance before the user can be closed")
+
+// ErrAccountClosed is returned when money would be credited to an account of a closed user
+var ErrAccountClosed = errors.New("the account belongs to a closed user")
+
+// CloseUserTxResult is the result of the close user transaction
+type CloseUserTxResult struct {
+	User User `json:"user"`
+	// BlockedSessions are the sessions of the closed user, their access tokens have to be revoked
+	BlockedSessions []Session `json:"blocked_sessions"`
+}
+
+// CloseUserTx closes an active user and erases its personal data, the accounts, entries and transfers are kept
+// since the ledger history has to be retained. Its sessions are blocked, its api keys revoked
+// and the scheduled transfers paid by or into its accounts are cancelled.
+// The transaction is rolled back with ErrNonZeroBalance if any account still holds money,
+// and it returns sql.ErrNoRows if the user doesn't exist or is already closed.
+func (s *SQLStore) CloseUserTx(ctx context.Context, username string) (CloseUserTxResult, error) {
+	var result CloseUserTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CloseUser(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		// the accounts are locked so a concurrent transfer can't fund them before the commit
+		accounts, err := q.ListAccountsForUpdateByOwner(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		for _, account := range accounts {
+			if account.Balance != 0 {
+				return ErrNonZeroBalance
+			}
+		}
+
+		// the pending verifications still hold the previous emails
+		err = q.DeleteUserVerifyEmails(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidatePasswordResetTokens(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		err = q.RevokeUserApiKeys(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		err = q.CancelUserScheduledTransfers(ctx, username)
+		if err != nil {
+			return err
+		}
+
+		result.BlockedSessions, err = q.BlockUserSessions(ctx, username)
+		return err
+	})
+
+	return result, err
+}
+
+// checkOwnerActive fails with ErrAccountClosed if the owner of the credited account is closed.
+// The account row must already be locked by the transaction, a concurrent CloseUserTx then either
+// committed before and is seen here, or waits for this transaction and finds a non-zero balance.
+func checkOwnerActive(ctx context.Context, q *Queries, account Account) error {
+	status, err := q.GetUserStatus(ctx, account.Owner)
+	if err != nil {
+		return err
+	}
+
+	if status != UserActive {
+		return ErrAccountClosed
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCloseUserTx(t *testing.T) {
+	store := NewStore(_testDB)
+	account := createRandomAccountWithBalance(t, 10)
+	other := createRandomAccountWithBalance(t, 10)
+	createSessionWithAccessToken(t, User{Username: account.Owner})
+
+	outgoing := createDueScheduledTransfer(t, account, other, 1)
+	incoming := createDueScheduledTransfer(t, other, account, 1)
+
+	// an account holding money keeps the user open
+	_, err := store.CloseUserTx(context.Background(), account.Owner)
+	require.ErrorIs(t, err, ErrNonZeroBalance)
+
+	user, err := store.GetUser(context.Background(), account.Owner)
+	require.NoError(t, err)
+	require.Equal(t, UserActive, user.Status)
+	require.NotEmpty(t, user.Email)
+
+	_, err = store.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: -10})
+	require.NoError(t, err)
+
+	result, err := store.CloseUserTx(context.Background(), account.Owner)
+	require.NoError(t, err)
+	require.Equal(t, UserClosed, result.User.Status)
+	require.True(t, result.User.ClosedAt.Valid)
+	require.Empty(t, result.User.FullName)
+	require.NotEqual(t, user.Email, result.User.Email)
+	require.Empty(t, result.User.HashedPassword)
+	require.Len(t, result.BlockedSessions, 1)
+
+	// the ledger history is retained
+	_, err = store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+
+	for _, scheduled := range []ScheduledTransfer{outgoing, incoming} {
+		updated, err := store.GetScheduledTransfer(context.Background(), scheduled.ID)
+		require.NoError(t, err)
+		require.Equal(t, ScheduledTransferCancelled, updated.Status)
+	}
+
+	// nothing can be credited to the accounts of a closed user
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: other.ID,
+		ToAccountID:   account.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	_, err = store.DepositTx(context.Background(), ExternalMovementTxParams{
+		AccountID: account.ID,
+		Amount:    1,
+		Source:    "external-bank",
+		Channel:   "cash",
+	})
+	require.ErrorIs(t, err, ErrAccountClosed)
+
+	updated, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Zero(t, updated.Balance)
+
+	_, err = store.CloseUserTx(context.Background(), account.Owner)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var ErrTotpAlreadyConfirmed = errors.New("two-factor authentication is already confirmed")
+
+// ConfirmTotpTxParams contains the input parameters of the totp confirmation transaction
+type ConfirmTotpTxParams struct {
+	Username string `json:"username"`
+	// Step is the time step of the code the user confirmed with, it can't be used again to login
+	Step                int64    `json:"step"`
+	HashedRecoveryCodes []string `json:"hashed_recovery_codes"`
+}
+
+// ConfirmTotpTx turns on two-factor authentication for the user and replaces its recovery codes
+func (s *SQLStore) ConfirmTotpTx(ctx context.Context, arg ConfirmTotpTxParams) (TotpSecret, error) {
+	var result TotpSecret
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result, err = q.ConfirmTotpSecret(ctx, ConfirmTotpSecretParams{
+			Username: arg.Username,
+			Step:     arg.Step,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrTotpAlreadyConfirmed
+			}
+
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, hashedCode := range arg.HashedRecoveryCodes {
+			_, err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username:   arg.Username,
+				HashedCode: hashedCode,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,79 @@
+package db
+
+import "context"
+
+// ExternalMovementTxParams contains the input parameters of the deposit and withdrawal transactions
+type ExternalMovementTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Amount    int64  `json:"amount"`
+	Source    string `json:"source"`
+	Reference string `json:"reference"`
+	Channel   string `json:"channel"`
+}
+
+// ExternalMovementTxResult is the result of the deposit and withdrawal transactions
+type ExternalMovementTxResult struct {
+	Account  Account          `json:"account"`
+	Entry    Entry            `json:"entry"`
+	Movement ExternalMovement `json:"movement"`
+}
+
+// DepositTx puts money coming from outside the bank into an account
+// It creates an entry, an external movement record and updates the account balance within a single database transaction
+// It fails with ErrAccountClosed if the account belongs to a closed user
+func (s *SQLStore) DepositTx(ctx context.Context, arg ExternalMovementTxParams) (ExternalMovementTxResult, error) {
+	return s.externalMovementTx(ctx, arg, arg.Amount)
+}
+
+// WithdrawTx takes money out of an account to somewhere outside the bank
+// It fails with ErrInsufficientFunds if the account ends up below its overdraft limit
+func (s *SQLStore) WithdrawTx(ctx context.Context, arg ExternalMovementTxParams) (ExternalMovementTxResult, error) {
+	return s.externalMovementTx(ctx, arg, -arg.Amount)
+}
+
+func (s *SQLStore) externalMovementTx(ctx context.Context, arg ExternalMovementTxParams, amount int64) (ExternalMovementTxResult, error) {
+	var result ExternalMovementTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: arg.AccountID,
+			Amount:    amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Movement, err = q.CreateExternalMovement(ctx, CreateExternalMovementParams{
+			AccountID: arg.AccountID,
+			EntryID:   result.Entry.ID,
+			Amount:    amount,
+			Source:    arg.Source,
+			Reference: arg.Reference,
+			Channel:   arg.Channel,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		if result.Account.Balance < -result.Account.OverdraftLimit {
+			return ErrInsufficientFunds
+		}
+
+		if amount > 0 {
+			return checkOwnerActive(ctx, q, result.Account)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDepositTx(t *testing.T) {
+	store := NewStore(_testDB)
+	account := createRandomAccountWithBalance(t, 0)
+
+	arg := ExternalMovementTxParams{
+		AccountID: account.ID,
+		Amount:    100,
+		Source:    "external-bank",
+		Reference: "invoice-1",
+		Channel:   "bank_transfer",
+	}
+
+	result, err := store.DepositTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), result.Account.Balance)
+	require.Equal(t, int64(100), result.Entry.Amount)
+	require.Equal(t, account.ID, result.Entry.AccountID)
+
+	require.Equal(t, result.Entry.ID, result.Movement.EntryID)
+	require.Equal(t, arg.Amount, result.Movement.Amount)
+	require.Equal(t, arg.Source, result.Movement.Source)
+	require.Equal(t, arg.Reference, result.Movement.Reference)
+	require.Equal(t, arg.Channel, result.Movement.Channel)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	store := NewStore(_testDB)
+	account := createRandomAccountWithBalance(t, 100)
+
+	arg := ExternalMovementTxParams{
+		AccountID: account.ID,
+		Amount:    60,
+		Source:    "atm-1",
+		Channel:   "cash",
+	}
+
+	result, err := store.WithdrawTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), result.Account.Balance)
+	require.Equal(t, int64(-60), result.Entry.Amount)
+	require.Equal(t, int64(-60), result.Movement.Amount)
+
+	_, err = store.WithdrawTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), updatedAccount.Balance)
+}
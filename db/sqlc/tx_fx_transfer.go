@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"math/big"
+	"time"
+)
+
+var (
+	ErrFxQuoteExpired = errors.New("fx quote has expired")
+	ErrFxQuoteUsed    = errors.New("fx quote has already been used")
+)
+
+// QuoteFxParams contains the input parameters of a currency conversion quote
+type QuoteFxParams struct {
+	Username     string        `json:"username"`
+	FromCurrency string        `json:"from_currency"`
+	ToCurrency   string        `json:"to_currency"`
+	FromAmount   int64         `json:"from_amount"`
+	Duration     time.Duration `json:"duration"`
+}
+
+// QuoteFx locks the current rate of a currency pair into a quote that stays valid for the given duration
+func (s *SQLStore) QuoteFx(ctx context.Context, arg QuoteFxParams) (FxQuote, error) {
+	fxRate, err := s.GetCurrentFxRate(ctx, GetCurrentFxRateParams{
+		BaseCurrency:  arg.FromCurrency,
+		QuoteCurrency: arg.ToCurrency,
+	})
+	if err != nil {
+		return FxQuote{}, err
+	}
+
+	toAmount, err := convertAmount(arg.FromAmount, fxRate.Rate)
+	if err != nil {
+		return FxQuote{}, err
+	}
+
+	quoteID, err := uuid.NewRandom()
+	if err != nil {
+		return FxQuote{}, err
+	}
+
+	return s.CreateFxQuote(ctx, CreateFxQuoteParams{
+		ID:           quoteID,
+		Username:     arg.Username,
+		FromCurrency: arg.FromCurrency,
+		ToCurrency:   arg.ToCurrency,
+		Rate:         fxRate.Rate,
+		FromAmount:   arg.FromAmount,
+		ToAmount:     toAmount,
+		ExpiresAt:    time.Now().Add(arg.Duration),
+	})
+}
+
+// convertAmount applies a decimal rate to an amount, rounding down to the smallest currency unit
+func convertAmount(amount int64, rate string) (int64, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok {
+		return 0, fmt.Errorf("invalid fx rate %q", rate)
+	}
+
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), r)
+	result := new(big.Int).Quo(converted.Num(), converted.Denom())
+	if !result.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows: %s", result)
+	}
+
+	return result.Int64(), nil
+}
+
+// FxTransferTxParams contains the input parameters of the converting transfer transaction
+type FxTransferTxParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	QuoteID       uuid.UUID `json:"quote_id"`
+}
+
+// FxTransferTx performs a money transfer between accounts of different currencies at the rate locked by a quote
+// The source account is debited by the quoted amount in its own currency and the target account is credited with the converted amount.
+// A quote can only be used once and only before it expires, and the target account can't belong to a closed user.
+func (s *SQLStore) FxTransferTx(ctx context.Context, arg FxTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		quote, err := q.GetFxQuoteForUpdate(ctx, arg.QuoteID)
+		if err != nil {
+			return err
+		}
+
+		if quote.TransferID.Valid {
+			return ErrFxQuoteUsed
+		}
+
+		if time.Now().After(quote.ExpiresAt) {
+			return ErrFxQuoteExpired
+		}
+
+		result, err = moveMoney(ctx, q, TransferTxParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        quote.FromAmount,
+		}, quote.ToAmount)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.UseFxQuote(ctx, UseFxQuoteParams{
+			ID:         quote.ID,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestConvertAmount(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Amount   int64
+		Rate     string
+		Expected int64
+	}{
+		{Name: "Identity", Amount: 100, Rate: "1", Expected: 100},
+		{Name: "Multiply", Amount: 100, Rate: "24350.5", Expected: 2435050},
+		{Name: "RoundDown", Amount: 100, Rate: "0.00004107", Expected: 0},
+		{Name: "Fraction", Amount: 1999, Rate: "0.92", Expected: 1839},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			converted, err := convertAmount(tc.Amount, tc.Rate)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, converted)
+		})
+	}
+
+	_, err := convertAmount(100, "not-a-rate")
+	require.Error(t, err)
+}
+
+func TestFxTransferTx(t *testing.T) {
+	store := NewStore(_testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	_, err := store.CreateFxRate(context.Background(), CreateFxRateParams{
+		BaseCurrency:  account1.Currency,
+		QuoteCurrency: account2.Currency,
+		Rate:          "2.5",
+		ValidFrom:     time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	quote, err := store.QuoteFx(context.Background(), QuoteFxParams{
+		Username:     account1.Owner,
+		FromCurrency: account1.Currency,
+		ToCurrency:   account2.Currency,
+		FromAmount:   100,
+		Duration:     time.Minute,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(250), quote.ToAmount)
+
+	arg := FxTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		QuoteID:       quote.ID,
+	}
+
+	result, err := store.FxTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(-100), result.FromEntry.Amount)
+	require.Equal(t, int64(250), result.ToEntry.Amount)
+	require.Equal(t, int64(900), result.FromAccount.Balance)
+	require.Equal(t, int64(250), result.ToAccount.Balance)
+
+	_, err = store.FxTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrFxQuoteUsed)
+}
+
+func TestFxTransferTxExpiredQuote(t *testing.T) {
+	store := NewStore(_testDB)
+
+	account1 := createRandomAccountWithBalance(t, 1000)
+	account2 := createRandomAccountWithBalance(t, 0)
+
+	_, err := store.CreateFxRate(context.Background(), CreateFxRateParams{
+		BaseCurrency:  account1.Currency,
+		QuoteCurrency: account2.Currency,
+		Rate:          "1.1",
+		ValidFrom:     time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	quote, err := store.QuoteFx(context.Background(), QuoteFxParams{
+		Username:     account1.Owner,
+		FromCurrency: account1.Currency,
+		ToCurrency:   account2.Currency,
+		FromAmount:   100,
+		Duration:     -time.Second,
+	})
+	require.NoError(t, err)
+
+	_, err = store.FxTransferTx(context.Background(), FxTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		QuoteID:       quote.ID,
+	})
+	require.ErrorIs(t, err, ErrFxQuoteExpired)
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidPasswordResetToken = errors.New("password reset token is invalid or has expired")
+
+// ChangePasswordTxParams contains the input parameters of the password change transaction
+type ChangePasswordTxParams struct {
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+	// CurrentAccessTokenID keeps the session the password is changed from, every other session is blocked
+	CurrentAccessTokenID uuid.UUID `json:"current_access_token_id"`
+}
+
+// PasswordTxResult is the result of the password change and reset transactions
+type PasswordTxResult struct {
+	User User `json:"user"`
+	// BlockedSessions are the sessions logged out by the new password, their access tokens have to be revoked
+	BlockedSessions []Session `json:"blocked_sessions"`
+}
+
+// ChangePasswordTx sets the new password of the user and logs out its other sessions,
+// pending reset tokens are invalidated since they were issued for the old password
+func (s *SQLStore) ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (PasswordTxResult, error) {
+	var result PasswordTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:       arg.Username,
+			HashedPassword: arg.HashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidatePasswordResetTokens(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.BlockedSessions, err = q.BlockOtherUserSessions(ctx, BlockOtherUserSessionsParams{
+			Username:             arg.Username,
+			CurrentAccessTokenID: arg.CurrentAccessTokenID,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ResetPasswordTxParams contains the input parameters of the password reset transaction
+type ResetPasswordTxParams struct {
+	HashedToken    string `json:"hashed_token"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+// ResetPasswordTx uses the reset token to set the new password of its user and logs out every session of the user.
+// The transaction is rolled back with ErrInvalidPasswordResetToken if the token is unknown, used or expired.
+func (s *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (PasswordTxResult, error) {
+	var result PasswordTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		resetToken, err := q.UsePasswordResetToken(ctx, arg.HashedToken)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrInvalidPasswordResetToken
+			}
+
+			return err
+		}
+
+		result.User, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:       resetToken.Username,
+			HashedPassword: arg.HashedPassword,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.InvalidatePasswordResetTokens(ctx, resetToken.Username)
+		if err != nil {
+			return err
+		}
+
+		result.BlockedSessions, err = q.BlockUserSessions(ctx, resetToken.Username)
+		return err
+	})
+
+	return result, err
+}
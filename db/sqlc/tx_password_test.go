@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/util/randutil"
+	"testing"
+	"time"
+)
+
+func createSessionWithAccessToken(t *testing.T, user User) Session {
+	id := uuid.New()
+	session, err := _testQueries.CreateSession(context.Background(), CreateSessionParams{
+		ID:                   id,
+		Username:             user.Username,
+		RefreshToken:         randutil.StringWithQuantity(32),
+		UserAgent:            "test-agent",
+		ClientIp:             "127.0.0.1",
+		ExpiresAt:            time.Now().Add(time.Hour),
+		FamilyID:             id,
+		AccessTokenID:        uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		AccessTokenExpiresAt: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+	})
+	require.NoError(t, err)
+
+	return session
+}
+
+func TestChangePasswordTx(t *testing.T) {
+	store := NewStore(_testDB)
+	user := createRandomUser(t)
+	current := createSessionWithAccessToken(t, user)
+	other := createSessionWithAccessToken(t, user)
+
+	result, err := store.ChangePasswordTx(context.Background(), ChangePasswordTxParams{
+		Username:             user.Username,
+		HashedPassword:       "new-hash",
+		CurrentAccessTokenID: current.AccessTokenID.UUID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "new-hash", result.User.HashedPassword)
+	require.WithinDuration(t, time.Now(), result.User.PasswordChangedAt, time.Second)
+
+	require.Len(t, result.BlockedSessions, 1)
+	require.Equal(t, other.ID, result.BlockedSessions[0].ID)
+
+	sessions, err := store.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, current.ID, sessions[0].ID)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(_testDB)
+	user := createRandomUser(t)
+	createSessionWithAccessToken(t, user)
+
+	resetToken, err := store.CreatePasswordResetToken(context.Background(), CreatePasswordResetTokenParams{
+		Username:    user.Username,
+		HashedToken: randutil.StringWithQuantity(64),
+		ExpiresAt:   time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	tokenUser, err := store.GetUserByPasswordResetToken(context.Background(), resetToken.HashedToken)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, tokenUser.Username)
+
+	arg := ResetPasswordTxParams{
+		HashedToken:    resetToken.HashedToken,
+		HashedPassword: "new-hash",
+	}
+
+	result, err := store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+	require.Equal(t, "new-hash", result.User.HashedPassword)
+	require.Len(t, result.BlockedSessions, 1)
+
+	// a reset token can only be used once
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidPasswordResetToken)
+
+	_, err = store.GetUserByPasswordResetToken(context.Background(), resetToken.HashedToken)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	expired, err := store.CreatePasswordResetToken(context.Background(), CreatePasswordResetTokenParams{
+		Username:    user.Username,
+		HashedToken: randutil.StringWithQuantity(64),
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		HashedToken:    expired.HashedToken,
+		HashedPassword: "new-hash",
+	})
+	require.ErrorIs(t, err, ErrInvalidPasswordResetToken)
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrTransferAlreadyReversed  = errors.New("transfer has already been fully reversed")
+	ErrReversalExceedsRemaining = errors.New("reversal amount exceeds the remaining amount of the transfer")
+	ErrReversalOfReversal       = errors.New("a reversal can't be reversed")
+	ErrReversalOfFxTransfer     = errors.New("a cross-currency transfer can't be reversed")
+)
+
+// ReverseTransferTxParams contains the input parameters of the reversal transaction
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount to refund, zero refunds whatever hasn't been reversed yet
+	Amount int64 `json:"amount"`
+}
+
+// ReverseTransferTx creates a compensating transfer from the recipient back to the sender of a transfer.
+// Several partial refunds are allowed as long as their sum doesn't exceed the original amount,
+// so a transfer can be fully reversed at most once.
+func (s *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		// locking the original transfer serializes concurrent reversals of it
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+
+		if original.ReversalOf.Valid {
+			return ErrReversalOfReversal
+		}
+
+		fromAccount, err := q.GetAccount(ctx, original.FromAccountID)
+		if err != nil {
+			return err
+		}
+
+		toAccount, err := q.GetAccount(ctx, original.ToAccountID)
+		if err != nil {
+			return err
+		}
+
+		if fromAccount.Currency != toAccount.Currency {
+			return ErrReversalOfFxTransfer
+		}
+
+		reversed, err := q.GetReversedAmount(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+
+		remaining := original.Amount - reversed
+		if remaining <= 0 {
+			return ErrTransferAlreadyReversed
+		}
+
+		amount := arg.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount > remaining {
+			return ErrReversalExceedsRemaining
+		}
+
+		result, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        amount,
+			ReversalOf:    sql.NullInt64{Int64: original.ID, Valid: true},
+		})
+
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+)
+
+var ErrSessionReused = errors.New("refresh token has already been rotated")
+
+// RotateSessionTxParams contains the input parameters of the session rotation transaction
+type RotateSessionTxParams struct {
+	OldSessionID uuid.UUID `json:"old_session_id"`
+	// NewSession is created in the family of the old session, its FamilyID is ignored
+	NewSession CreateSessionParams `json:"new_session"`
+}
+
+// RotateSessionTx replaces a session by a new one carrying a fresh refresh token.
+// The old session is marked as replaced with a conditional update, so of two concurrent renewals
+// with the same refresh token only one succeeds and the other gets ErrSessionReused.
+// A blocked session can't be rotated either and also yields ErrSessionReused.
+func (s *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error) {
+	var result Session
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		old, err := q.RotateSession(ctx, RotateSessionParams{
+			ID:         arg.OldSessionID,
+			ReplacedBy: arg.NewSession.ID,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrSessionReused
+			}
+
+			return err
+		}
+
+		newSession := arg.NewSession
+		newSession.FamilyID = old.FamilyID
+
+		result, err = q.CreateSession(ctx, newSession)
+		return err
+	})
+
+	return result, err
+}
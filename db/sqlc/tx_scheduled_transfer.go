@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/thehaung/simplebank/util/scheduleutil"
+	"time"
+)
+
+// Scheduled transfer and run statuses
+const (
+	ScheduledTransferActive    = "active"
+	ScheduledTransferPaused    = "paused"
+	ScheduledTransferCancelled = "cancelled"
+
+	ScheduledTransferRunSucceeded = "succeeded"
+	ScheduledTransferRunFailed    = "failed"
+)
+
+const _scheduledTransferSavepoint = "scheduled_transfer"
+
+// RunScheduledTransferTx executes the most overdue active scheduled transfer, if any.
+// The scheduled row is claimed with FOR UPDATE SKIP LOCKED so several schedulers can run side by side.
+// A failed transfer is rolled back to a savepoint and recorded as a failed run, and in both cases
+// the next run is moved forward within the same database transaction.
+// It returns sql.ErrNoRows when nothing is due.
+func (s *SQLStore) RunScheduledTransferTx(ctx context.Context) (ScheduledTransferRun, error) {
+	var run ScheduledTransferRun
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		scheduled, err := q.ClaimDueScheduledTransfer(ctx)
+		if err != nil {
+			return err
+		}
+
+		runArg := CreateScheduledTransferRunParams{
+			ScheduledTransferID: scheduled.ID,
+			Status:              ScheduledTransferRunSucceeded,
+		}
+
+		result, err := s.runScheduledTransfer(ctx, q, scheduled)
+		if err != nil {
+			runArg.Status = ScheduledTransferRunFailed
+			runArg.ErrorMessage = err.Error()
+		} else {
+			runArg.TransferID = sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
+		}
+
+		run, err = q.CreateScheduledTransferRun(ctx, runArg)
+		if err != nil {
+			return err
+		}
+
+		nextRunAt, err := scheduleutil.Next(scheduled.Schedule, scheduled.StartAt, scheduled.NextRunAt, time.Now())
+		if err != nil {
+			return err
+		}
+
+		_, err = q.UpdateScheduledTransfer(ctx, UpdateScheduledTransferParams{
+			ID:        scheduled.ID,
+			NextRunAt: sql.NullTime{Time: nextRunAt, Valid: true},
+		})
+
+		return err
+	})
+
+	return run, err
+}
+
+// runScheduledTransfer performs the transfer inside a savepoint so its failure doesn't abort the outer transaction
+func (s *SQLStore) runScheduledTransfer(ctx context.Context, q *Queries, scheduled ScheduledTransfer) (TransferTxResult, error) {
+	_, err := q.db.ExecContext(ctx, "SAVEPOINT "+_scheduledTransferSavepoint)
+	if err != nil {
+		return TransferTxResult{}, err
+	}
+
+	result, err := transfer(ctx, q, TransferTxParams{
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		Amount:        scheduled.Amount,
+	})
+	if err != nil {
+		_, rbErr := q.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+_scheduledTransferSavepoint)
+		if rbErr != nil {
+			return result, rbErr
+		}
+
+		return result, err
+	}
+
+	_, err = q.db.ExecContext(ctx, "RELEASE SAVEPOINT "+_scheduledTransferSavepoint)
+	return result, err
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func createDueScheduledTransfer(t *testing.T, from, to Account, amount int64) ScheduledTransfer {
+	scheduled, err := _testQueries.CreateScheduledTransfer(context.Background(), CreateScheduledTransferParams{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        amount,
+		Currency:      from.Currency,
+		Schedule:      "@daily",
+		NextRunAt:     time.Now().Add(-time.Hour * 24 * 365),
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferActive, scheduled.Status)
+	require.True(t, scheduled.StartAt.Equal(scheduled.NextRunAt))
+
+	return scheduled
+}
+
+// runUntil keeps executing due transfers until the given scheduled transfer has run
+func runUntil(t *testing.T, store Store, scheduledID int64) ScheduledTransferRun {
+	for {
+		run, err := store.RunScheduledTransferTx(context.Background())
+		require.NoError(t, err)
+
+		if run.ScheduledTransferID == scheduledID {
+			return run
+		}
+	}
+}
+
+func TestRunScheduledTransferTx(t *testing.T) {
+	store := NewStore(_testDB)
+
+	account1 := createRandomAccountWithBalance(t, 100)
+	account2 := createRandomAccountWithBalance(t, 0)
+	scheduled := createDueScheduledTransfer(t, account1, account2, 40)
+
+	run := runUntil(t, store, scheduled.ID)
+	require.Equal(t, ScheduledTransferRunSucceeded, run.Status)
+	require.True(t, run.TransferID.Valid)
+
+	updated, err := store.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.True(t, updated.NextRunAt.After(time.Now()))
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(60), updatedAccount1.Balance)
+}
+
+func TestRunScheduledTransferTxFailure(t *testing.T) {
+	store := NewStore(_testDB)
+
+	account1 := createRandomAccountWithBalance(t, 10)
+	account2 := createRandomAccountWithBalance(t, 0)
+	scheduled := createDueScheduledTransfer(t, account1, account2, 40)
+
+	run := runUntil(t, store, scheduled.ID)
+	require.Equal(t, ScheduledTransferRunFailed, run.Status)
+	require.False(t, run.TransferID.Valid)
+	require.Equal(t, ErrInsufficientFunds.Error(), run.ErrorMessage)
+
+	updated, err := store.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.True(t, updated.NextRunAt.After(time.Now()))
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// UpdateUserTxParams contains the input parameters of the update user transaction
+type UpdateUserTxParams struct {
+	UpdateUserParams
+	// HashedSecretCode and VerifyEmailExpiresAt describe the code a new email is verified with
+	HashedSecretCode     string    `json:"hashed_secret_code"`
+	VerifyEmailExpiresAt time.Time `json:"verify_email_expires_at"`
+	// AfterEmailChange is called before committing when the email has to be verified again,
+	// an error rolls the update back
+	AfterEmailChange func(user User, verifyEmail VerifyEmail) error `json:"-"`
+}
+
+// UpdateUserTxResult is the result of the update user transaction
+type UpdateUserTxResult struct {
+	User User `json:"user"`
+	// VerifyEmail is only set when the email changed
+	VerifyEmail *VerifyEmail `json:"verify_email"`
+}
+
+// UpdateUserTx updates the provided fields of the user,
+// a changed email is no longer verified and gets a new verification code
+func (s *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if !arg.Email.Valid || result.User.IsEmailVerified {
+			return nil
+		}
+
+		verifyEmail, err := q.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+			Username:         result.User.Username,
+			Email:            result.User.Email,
+			HashedSecretCode: arg.HashedSecretCode,
+			ExpiresAt:        arg.VerifyEmailExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+		result.VerifyEmail = &verifyEmail
+
+		if arg.AfterEmailChange == nil {
+			return nil
+		}
+
+		return arg.AfterEmailChange(result.User, verifyEmail)
+	})
+
+	return result, err
+}
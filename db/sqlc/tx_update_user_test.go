@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/util/randutil"
+	"testing"
+	"time"
+)
+
+func TestUpdateUserTxFullName(t *testing.T) {
+	store := NewStore(_testDB)
+	hashedSecretCode := randutil.StringWithQuantity(64)
+	created := createUserTx(t, store, hashedSecretCode, time.Now().Add(time.Hour))
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:          created.VerifyEmail.ID,
+		HashedSecretCode: hashedSecretCode,
+	})
+	require.NoError(t, err)
+
+	newFullName := randutil.Owner()
+	result, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username: created.User.Username,
+			FullName: sql.NullString{String: newFullName, Valid: true},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullName, result.User.FullName)
+	require.Equal(t, created.User.Email, result.User.Email)
+	require.True(t, result.User.IsEmailVerified)
+	require.Nil(t, result.VerifyEmail)
+}
+
+func TestUpdateUserTxEmail(t *testing.T) {
+	store := NewStore(_testDB)
+	hashedSecretCode := randutil.StringWithQuantity(64)
+	created := createUserTx(t, store, hashedSecretCode, time.Now().Add(time.Hour))
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:          created.VerifyEmail.ID,
+		HashedSecretCode: hashedSecretCode,
+	})
+	require.NoError(t, err)
+
+	newEmail := randutil.Email()
+	var sent VerifyEmail
+	result, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username: created.User.Username,
+			Email:    sql.NullString{String: newEmail, Valid: true},
+		},
+		HashedSecretCode:     randutil.StringWithQuantity(64),
+		VerifyEmailExpiresAt: time.Now().Add(time.Hour),
+		AfterEmailChange: func(user User, verifyEmail VerifyEmail) error {
+			sent = verifyEmail
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, result.User.Email)
+	require.Equal(t, created.User.FullName, result.User.FullName)
+	require.False(t, result.User.IsEmailVerified)
+	require.NotNil(t, result.VerifyEmail)
+	require.Equal(t, newEmail, sent.Email)
+
+	// the code sent to the old email can't verify the new one
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:          created.VerifyEmail.ID,
+		HashedSecretCode: hashedSecretCode,
+	})
+	require.ErrorIs(t, err, ErrInvalidVerifyEmail)
+}
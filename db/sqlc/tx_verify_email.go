@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrInvalidVerifyEmail = errors.New("email verification code is invalid or has expired")
+
+// CreateUserTxParams contains the input parameters of the create user transaction
+type CreateUserTxParams struct {
+	CreateUserParams
+	// HashedSecretCode and VerifyEmailExpiresAt describe the code the new email is verified with
+	HashedSecretCode     string    `json:"hashed_secret_code"`
+	VerifyEmailExpiresAt time.Time `json:"verify_email_expires_at"`
+	// AfterCreate is called before committing, an error rolls the user back
+	// so no user is created without its verification email
+	AfterCreate func(user User, verifyEmail VerifyEmail) error `json:"-"`
+}
+
+// CreateUserTxResult is the result of the create user transaction
+type CreateUserTxResult struct {
+	User        User        `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// CreateUserTx creates the user together with the code its email is verified with
+func (s *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.VerifyEmail, err = q.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+			Username:         result.User.Username,
+			Email:            result.User.Email,
+			HashedSecretCode: arg.HashedSecretCode,
+			ExpiresAt:        arg.VerifyEmailExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.AfterCreate == nil {
+			return nil
+		}
+
+		return arg.AfterCreate(result.User, result.VerifyEmail)
+	})
+
+	return result, err
+}
+
+// VerifyEmailTxParams contains the input parameters of the verify email transaction
+type VerifyEmailTxParams struct {
+	EmailID          int64  `json:"email_id"`
+	HashedSecretCode string `json:"hashed_secret_code"`
+}
+
+// VerifyEmailTxResult is the result of the verify email transaction
+type VerifyEmailTxResult struct {
+	User        User        `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// VerifyEmailTx uses the secret code to mark the email of its user as verified.
+// The transaction is rolled back with ErrInvalidVerifyEmail if the code is unknown, used or expired,
+// or if the user changed its email since the code was sent.
+func (s *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := s.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.VerifyEmail, err = q.UseVerifyEmail(ctx, UseVerifyEmailParams{
+			ID:               arg.EmailID,
+			HashedSecretCode: arg.HashedSecretCode,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrInvalidVerifyEmail
+			}
+
+			return err
+		}
+
+		result.User, err = q.VerifyUserEmail(ctx, VerifyUserEmailParams{
+			Username: result.VerifyEmail.Username,
+			Email:    result.VerifyEmail.Email,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrInvalidVerifyEmail
+			}
+
+			return err
+		}
+
+		return nil
+	})
+
+	return result, err
+}
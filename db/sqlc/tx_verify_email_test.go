@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/util/randutil"
+	"testing"
+	"time"
+)
+
+func createUserTx(t *testing.T, store Store, hashedSecretCode string, expiresAt time.Time) CreateUserTxResult {
+	result, err := store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       randutil.Owner(),
+			HashedPassword: "hash",
+			FullName:       randutil.Owner(),
+			Email:          randutil.Email(),
+		},
+		HashedSecretCode:     hashedSecretCode,
+		VerifyEmailExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	require.False(t, result.User.IsEmailVerified)
+	require.Equal(t, result.User.Email, result.VerifyEmail.Email)
+
+	return result
+}
+
+func TestCreateUserTxRollback(t *testing.T) {
+	store := NewStore(_testDB)
+	errMailer := errors.New("mailer is down")
+
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       randutil.Owner(),
+			HashedPassword: "hash",
+			FullName:       randutil.Owner(),
+			Email:          randutil.Email(),
+		},
+		HashedSecretCode:     randutil.StringWithQuantity(64),
+		VerifyEmailExpiresAt: time.Now().Add(time.Hour),
+		AfterCreate: func(user User, verifyEmail VerifyEmail) error {
+			return errMailer
+		},
+	}
+
+	_, err := store.CreateUserTx(context.Background(), arg)
+	require.ErrorIs(t, err, errMailer)
+
+	_, err = store.GetUser(context.Background(), arg.Username)
+	require.Error(t, err)
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(_testDB)
+	hashedSecretCode := randutil.StringWithQuantity(64)
+	created := createUserTx(t, store, hashedSecretCode, time.Now().Add(time.Hour))
+
+	arg := VerifyEmailTxParams{
+		EmailID:          created.VerifyEmail.ID,
+		HashedSecretCode: hashedSecretCode,
+	}
+
+	result, err := store.VerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.User.IsEmailVerified)
+	require.True(t, result.VerifyEmail.IsUsed)
+
+	// a code can only be used once
+	_, err = store.VerifyEmailTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidVerifyEmail)
+}
+
+func TestVerifyEmailTxExpired(t *testing.T) {
+	store := NewStore(_testDB)
+	hashedSecretCode := randutil.StringWithQuantity(64)
+	created := createUserTx(t, store, hashedSecretCode, time.Now().Add(-time.Minute))
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:          created.VerifyEmail.ID,
+		HashedSecretCode: hashedSecretCode,
+	})
+	require.ErrorIs(t, err, ErrInvalidVerifyEmail)
+}
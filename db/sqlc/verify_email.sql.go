@@ -0,0 +1,83 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.15.0
+// source: verify_email.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createVerifyEmail = `-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (username, email, hashed_secret_code, expires_at)
+VALUES ($1, $2, $3, $4) RETURNING id, username, email, hashed_secret_code, is_used, expires_at, created_at
+`
+
+type CreateVerifyEmailParams struct {
+	Username         string    `json:"username"`
+	Email            string    `json:"email"`
+	HashedSecretCode string    `json:"hashed_secret_code"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error) {
+	row := q.db.QueryRowContext(ctx, createVerifyEmail,
+		arg.Username,
+		arg.Email,
+		arg.HashedSecretCode,
+		arg.ExpiresAt,
+	)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedSecretCode,
+		&i.IsUsed,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteUserVerifyEmails = `-- name: DeleteUserVerifyEmails :exec
+DELETE
+FROM verify_emails
+WHERE username = $1
+`
+
+func (q *Queries) DeleteUserVerifyEmails(ctx context.Context, username string) error {
+	_, err := q.db.ExecContext(ctx, deleteUserVerifyEmails, username)
+	return err
+}
+
+const useVerifyEmail = `-- name: UseVerifyEmail :one
+UPDATE verify_emails
+SET is_used = true
+WHERE id = $1
+  AND hashed_secret_code = $2
+  AND is_used = false
+  AND expires_at > now() RETURNING id, username, email, hashed_secret_code, is_used, expires_at, created_at
+`
+
+type UseVerifyEmailParams struct {
+	ID               int64  `json:"id"`
+	HashedSecretCode string `json:"hashed_secret_code"`
+}
+
+func (q *Queries) UseVerifyEmail(ctx context.Context, arg UseVerifyEmailParams) (VerifyEmail, error) {
+	row := q.db.QueryRowContext(ctx, useVerifyEmail, arg.ID, arg.HashedSecretCode)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.HashedSecretCode,
+		&i.IsUsed,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
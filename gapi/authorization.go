@@ -0,0 +1,86 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+const (
+	_authorizationHeaderKey    = "authorization"
+	_authorizationHeaderLength = 2
+	_authorizationHeaderBearer = "bearer"
+)
+
+// authorizeUser verifies the bearer token carried in the gRPC metadata
+func (s *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, errors.New("missing metadata")
+	}
+
+	values := md.Get(_authorizationHeaderKey)
+	if len(values) == 0 {
+		return nil, errors.New("authorization header is not provided")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) < _authorizationHeaderLength {
+		return nil, errors.New("invalid authorization header format")
+	}
+
+	// Index 0 is type 1 is token
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != _authorizationHeaderBearer {
+		return nil, fmt.Errorf("unsupported authorization type %s", authorizationType)
+	}
+
+	payload, err := s.tokenMaker.VerifyToken(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %w", err)
+	}
+
+	if payload.IsMfaPending() {
+		return nil, errors.New("two-factor authentication is pending")
+	}
+
+	if payload.IsRefresh() {
+		return nil, errors.New("refresh tokens can't be used as access tokens")
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("cannot check access token: %w", err)
+	}
+
+	if revoked {
+		return nil, errors.New("access token has been revoked")
+	}
+
+	userStatus, err := s.store.GetUserStatus(ctx, payload.Username)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("cannot check user: %w", err)
+	}
+
+	if err == sql.ErrNoRows || userStatus != db.UserActive {
+		return nil, errors.New("user is closed")
+	}
+
+	return payload, nil
+}
+
+// requireScope makes sure the token grants the scope needed by the RPC
+func requireScope(payload *token.Payload, scope string) error {
+	if !payload.HasScope(scope) {
+		return status.Errorf(codes.PermissionDenied, "token doesn't grant the %s scope", scope)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/config"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/randutil"
+	"google.golang.org/grpc/metadata"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T, store db.Store) *Server {
+	conf := &config.Config{
+		TokenSymmetricKey:   randutil.StringWithQuantity(32),
+		AccessTokenDuration: time.Minute,
+	}
+
+	// tokens are not revoked, logins are not locked and users are active unless a test expects otherwise
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().
+			GetRevokedToken(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(db.RevokedToken{}, sql.ErrNoRows)
+		mockStore.EXPECT().
+			GetLoginThrottle(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(db.LoginThrottle{}, sql.ErrNoRows)
+		mockStore.EXPECT().
+			RecordLoginFailure(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(db.LoginThrottle{Failures: 1}, nil)
+		mockStore.EXPECT().
+			DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+			AnyTimes()
+		mockStore.EXPECT().
+			GetUserStatus(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(db.UserActive, nil)
+	}
+
+	server, err := NewGrpcServer(conf, store)
+	require.NoError(t, err)
+
+	return server
+}
+
+func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, role string, duration time.Duration) context.Context {
+	accessToken, _, err := tokenMaker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+
+	bearerToken := fmt.Sprintf("%s %s", _authorizationHeaderBearer, accessToken)
+	md := metadata.MD{
+		_authorizationHeaderKey: []string{bearerToken},
+	}
+
+	return metadata.NewIncomingContext(context.Background(), md)
+}
@@ -0,0 +1,83 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"github.com/lib/pq"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/pb"
+	"github.com/thehaung/simplebank/util/secretutil"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+func (s *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	violations := s.validateCreateUserRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	hashedPassword, err := s.hasher.Hash(req.GetPassword())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
+	}
+
+	secretCode, err := secretutil.Generate()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate verification code: %s", err)
+	}
+
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.GetUsername(),
+			HashedPassword: hashedPassword,
+			FullName:       req.GetFullName(),
+			Email:          req.GetEmail(),
+		},
+		HashedSecretCode:     secretutil.Hash(secretCode),
+		VerifyEmailExpiresAt: time.Now().Add(s.cfg.VerifyEmailDuration),
+		AfterCreate: func(user db.User, verifyEmail db.VerifyEmail) error {
+			return s.sendVerifyEmail(ctx, user, verifyEmail, secretCode)
+		},
+	}
+
+	result, err := s.store.CreateUserTx(ctx, arg)
+	if err != nil {
+		pgErr, ok := err.(*pq.Error)
+		if ok {
+			switch pgErr.Code.Name() {
+			case "unique_violation":
+				return nil, status.Errorf(codes.AlreadyExists, "username already exists: %s", err)
+			}
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create user: %s", err)
+	}
+
+	resp := &pb.CreateUserResponse{
+		User: convertUser(result.User),
+	}
+
+	return resp, nil
+}
+
+func (s *Server) validateCreateUserRequest(req *pb.CreateUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+
+	for _, violation := range s.passwords.Validate(req.GetPassword(), req.GetUsername(), req.GetEmail()) {
+		violations = append(violations, fieldViolation("password", errors.New(violation.Description)))
+	}
+
+	if err := validateFullName(req.GetFullName()); err != nil {
+		violations = append(violations, fieldViolation("full_name", err))
+	}
+
+	if err := validateEmail(req.GetEmail()); err != nil {
+		violations = append(violations, fieldViolation("email", err))
+	}
+
+	return violations
+}
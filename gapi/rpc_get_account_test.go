@@ -0,0 +1,168 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/pb"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/randutil"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestGetAccountRPC(t *testing.T) {
+	account := db.Account{
+		ID:       randutil.IntWithRange(1, 1000),
+		Owner:    randutil.Owner(),
+		Balance:  randutil.Money(),
+		Currency: randutil.Currency(),
+	}
+
+	testCases := []struct {
+		Name          string
+		AccountID     int64
+		BuildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, resp *pb.GetAccountResponse, err error)
+	}{
+		{
+			Name:      "OK",
+			AccountID: account.ID,
+			BuildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, roleutil.Depositor, time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, resp.GetAccount().GetId())
+				require.Equal(t, account.Owner, resp.GetAccount().GetOwner())
+				require.Equal(t, account.Balance, resp.GetAccount().GetBalance())
+			},
+		},
+		{
+			Name:      "InvalidID",
+			AccountID: -1,
+			BuildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, roleutil.Depositor, time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			Name:      "NotFound",
+			AccountID: account.ID,
+			BuildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, roleutil.Depositor, time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			Name:      "PermissionDenied",
+			AccountID: account.ID,
+			BuildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, "someoneelse", roleutil.Depositor, time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			Name:      "NoAuthorization",
+			AccountID: account.ID,
+			BuildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			Name:      "ClosedUser",
+			AccountID: account.ID,
+			BuildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, roleutil.Depositor, time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserStatus(gomock.Any(), gomock.Eq(account.Owner)).
+					Times(1).
+					Return(db.UserClosed, nil)
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			Name:      "ExpiredToken",
+			AccountID: account.ID,
+			BuildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, account.Owner, roleutil.Depositor, -time.Minute)
+			},
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+			ctx := tc.BuildContext(t, server.tokenMaker)
+
+			resp, err := server.GetAccount(ctx, &pb.GetAccountRequest{Id: tc.AccountID})
+			tc.CheckResponse(t, resp, err)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/lockout"
+	"github.com/thehaung/simplebank/mfa"
+	"github.com/thehaung/simplebank/pb"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/hashutil"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	violations := validateLoginUserRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	mtdt := s.extractMetadata(ctx)
+	err := s.loginGuard.Check(ctx, req.GetUsername(), mtdt.ClientIP)
+	if err != nil {
+		return nil, loginGuardError(err)
+	}
+
+	// an unknown username gets the same error as a wrong password so usernames can't be enumerated
+	user, err := s.store.GetUser(ctx, req.GetUsername())
+	if err != nil && err != sql.ErrNoRows {
+		return nil, status.Errorf(codes.Internal, "failed to find user: %s", err)
+	}
+
+	if err == sql.ErrNoRows || user.Status != db.UserActive {
+		hashutil.CheckDummyPassword(s.hasher, req.GetPassword())
+		return nil, s.rejectLogin(ctx, req.GetUsername(), lockout.ErrIncorrectCredentials)
+	}
+
+	err = hashutil.CheckPassword(req.GetPassword(), user.HashedPassword)
+	if err != nil {
+		return nil, s.rejectLogin(ctx, req.GetUsername(), lockout.ErrIncorrectCredentials)
+	}
+
+	err = s.rehashPassword(ctx, user, req.GetPassword())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to rehash password: %s", err)
+	}
+
+	enabled, err := mfa.IsEnabled(ctx, s.store, user.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check two-factor authentication: %s", err)
+	}
+
+	if enabled {
+		mfaToken, mfaPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, s.cfg.MfaTokenDuration, token.ScopeMfaPending)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to create mfa token: %s", err)
+		}
+
+		resp := &pb.LoginUserResponse{
+			MfaRequired:       true,
+			MfaToken:          mfaToken,
+			MfaTokenExpiresAt: timestamppb.New(mfaPayload.ExpiredAt),
+		}
+
+		return resp, nil
+	}
+
+	return s.createLoginSession(ctx, user)
+}
+
+// rehashPassword hashes the password again when its stored hash was made with outdated parameters,
+// it's only possible at login since it needs the plain password
+func (s *Server) rehashPassword(ctx context.Context, user db.User, password string) error {
+	if !s.hasher.NeedsRehash(user.HashedPassword) {
+		return nil
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	// the password may have changed since it was checked, the new one must not be overwritten
+	_, err = s.store.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+		NewHashedPassword: hashedPassword,
+		Username:          user.Username,
+		OldHashedPassword: user.HashedPassword,
+	})
+	return err
+}
+
+// createLoginSession issues the access and refresh tokens of a user who proved its identity
+func (s *Server) createLoginSession(ctx context.Context, user db.User) (*pb.LoginUserResponse, error) {
+	err := s.loginGuard.RecordSuccess(ctx, user.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to reset failed logins: %s", err)
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, s.cfg.AccessTokenDuration)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create access token: %s", err)
+	}
+
+	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, s.cfg.RefreshTokenDuration, token.ScopeRefresh)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create refresh token: %s", err)
+	}
+
+	mtdt := s.extractMetadata(ctx)
+	session, err := s.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:                   refreshPayload.ID,
+		Username:             user.Username,
+		RefreshToken:         refreshToken,
+		UserAgent:            mtdt.UserAgent,
+		ClientIp:             mtdt.ClientIP,
+		IsBlocked:            false,
+		ExpiresAt:            refreshPayload.ExpiredAt,
+		FamilyID:             refreshPayload.ID,
+		AccessTokenID:        uuid.NullUUID{UUID: accessPayload.ID, Valid: true},
+		AccessTokenExpiresAt: sql.NullTime{Time: accessPayload.ExpiredAt, Valid: true},
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create session: %s", err)
+	}
+
+	resp := &pb.LoginUserResponse{
+		User:                  convertUser(user),
+		SessionId:             session.ID.String(),
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.ExpiredAt),
+	}
+
+	return resp, nil
+}
+
+// rejectLogin counts a failed login towards the lockout of the username and the client ip
+func (s *Server) rejectLogin(ctx context.Context, username string, reason error) error {
+	err := s.loginGuard.RecordFailure(ctx, username, s.extractMetadata(ctx).ClientIP)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to record failed login: %s", err)
+	}
+
+	return unauthenticatedError(reason)
+}
+
+func loginGuardError(err error) error {
+	var lockedErr *lockout.LockedError
+	if errors.As(err, &lockedErr) {
+		return status.Errorf(codes.ResourceExhausted, "%s", err)
+	}
+
+	return status.Errorf(codes.Internal, "failed to check failed logins: %s", err)
+}
+
+func validateLoginUserRequest(req *pb.LoginUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := validateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+
+	// the password policy is only enforced when a password is set, a login just needs one
+	if len(req.GetPassword()) == 0 {
+		violations = append(violations, fieldViolation("password", errors.New("is required")))
+	}
+
+	return violations
+}
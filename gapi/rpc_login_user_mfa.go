@@ -0,0 +1,92 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/mfa"
+	"github.com/thehaung/simplebank/pb"
+	"github.com/thehaung/simplebank/util/totputil"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"regexp"
+)
+
+var isValidTotpCode = regexp.MustCompile(`^[0-9]{6}$`).MatchString
+
+func (s *Server) LoginUserMfa(ctx context.Context, req *pb.LoginUserMfaRequest) (*pb.LoginUserResponse, error) {
+	violations := validateLoginUserMfaRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	mfaPayload, err := s.tokenMaker.VerifyToken(req.GetMfaToken())
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	if !mfaPayload.IsMfaPending() {
+		return nil, unauthenticatedError(errors.New("not an mfa token"))
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, mfaPayload)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check mfa token: %s", err)
+	}
+
+	if revoked {
+		return nil, unauthenticatedError(errors.New("mfa token has already been used"))
+	}
+
+	err = s.loginGuard.Check(ctx, mfaPayload.Username, s.extractMetadata(ctx).ClientIP)
+	if err != nil {
+		return nil, loginGuardError(err)
+	}
+
+	err = mfa.Verify(ctx, s.store, mfaPayload.Username, req.GetCode(), req.GetRecoveryCode())
+	if err != nil {
+		if errors.Is(err, mfa.ErrInvalidCode) {
+			return nil, s.rejectLogin(ctx, mfaPayload.Username, err)
+		}
+
+		if errors.Is(err, mfa.ErrNotEnrolled) {
+			return nil, unauthenticatedError(err)
+		}
+
+		return nil, status.Errorf(codes.Internal, "failed to verify two-factor code: %s", err)
+	}
+
+	err = s.revocations.Revoke(ctx, mfaPayload.ID, mfaPayload.Username, mfaPayload.ExpiredAt)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke mfa token: %s", err)
+	}
+
+	user, err := s.store.GetUser(ctx, mfaPayload.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to find user: %s", err)
+	}
+
+	if user.Status != db.UserActive {
+		return nil, status.Errorf(codes.Unauthenticated, "user is closed")
+	}
+
+	return s.createLoginSession(ctx, user)
+}
+
+func validateLoginUserMfaRequest(req *pb.LoginUserMfaRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if len(req.GetMfaToken()) == 0 {
+		violations = append(violations, fieldViolation("mfa_token", errors.New("is required")))
+	}
+
+	if len(req.GetRecoveryCode()) > 0 {
+		return violations
+	}
+
+	if !isValidTotpCode(req.GetCode()) {
+		violations = append(violations, fieldViolation("code", fmt.Errorf("must contain %d digits", totputil.Digits)))
+	}
+
+	return violations
+}
@@ -0,0 +1,124 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/pb"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/randutil"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"github.com/thehaung/simplebank/util/totputil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestLoginUserMfaRPC(t *testing.T) {
+	user := db.User{
+		Username: randutil.Owner(),
+		FullName: randutil.Owner(),
+		Email:    randutil.Email(),
+		Role:     roleutil.Depositor,
+		Status:   db.UserActive,
+	}
+
+	secret, err := totputil.GenerateSecret()
+	require.NoError(t, err)
+
+	totpSecret := db.TotpSecret{
+		Username:    user.Username,
+		Secret:      secret,
+		ConfirmedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+
+	code, err := totputil.Code(secret, totputil.Step(time.Now()))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		Name          string
+		Scopes        []string
+		Code          string
+		BuildStubs    func(store *mockdb.MockStore)
+		CheckResponse func(t *testing.T, resp *pb.LoginUserResponse, err error)
+	}{
+		{
+			Name:   "OK",
+			Scopes: []string{token.ScopeMfaPending},
+			Code:   code,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(totpSecret, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(totpSecret, nil)
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, resp.GetAccessToken())
+				require.NotEmpty(t, resp.GetRefreshToken())
+				require.False(t, resp.GetMfaRequired())
+			},
+		},
+		{
+			Name:   "ReplayedCode",
+			Scopes: []string{token.ScopeMfaPending},
+			Code:   code,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(totpSecret, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(db.TotpSecret{}, sql.ErrNoRows)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			Name: "NotAnMfaToken",
+			Code: code,
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			Name:   "InvalidCode",
+			Scopes: []string{token.ScopeMfaPending},
+			Code:   "12345",
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			CheckResponse: func(t *testing.T, resp *pb.LoginUserResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			server := newTestServer(t, store)
+
+			mfaToken, _, err := server.tokenMaker.CreateToken(user.Username, user.Role, time.Minute, tc.Scopes...)
+			require.NoError(t, err)
+
+			resp, err := server.LoginUserMfa(context.Background(), &pb.LoginUserMfaRequest{
+				MfaToken: mfaToken,
+				Code:     tc.Code,
+			})
+			tc.CheckResponse(t, resp, err)
+		})
+	}
+}
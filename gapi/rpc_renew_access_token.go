@@ -0,0 +1,133 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/pb"
+	"github.com/thehaung/simplebank/token"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+func (s *Server) RenewAccessToken(ctx context.Context, req *pb.RenewAccessTokenRequest) (*pb.RenewAccessTokenResponse, error) {
+	violations := validateRenewAccessTokenRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	refreshPayload, err := s.tokenMaker.VerifyToken(req.GetRefreshToken())
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	session, err := s.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+
+		return nil, status.Errorf(codes.Internal, "failed to find session: %s", err)
+	}
+
+	if session.ReplacedBy.Valid {
+		return nil, s.blockSessionFamily(ctx, session.FamilyID)
+	}
+
+	if session.IsBlocked {
+		return nil, unauthenticatedError(errors.New("blocked session"))
+	}
+
+	if session.Username != refreshPayload.Username {
+		return nil, unauthenticatedError(errors.New("incorrect session user"))
+	}
+
+	if session.RefreshToken != req.GetRefreshToken() {
+		return nil, unauthenticatedError(errors.New("mismatched session token"))
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return nil, unauthenticatedError(errors.New("expired session"))
+	}
+
+	// the role is read again so a role change applies to the sessions that keep renewing
+	user, err := s.store.GetUser(ctx, session.Username)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, status.Errorf(codes.Internal, "failed to find user: %s", err)
+	}
+
+	if err == sql.ErrNoRows || user.Status != db.UserActive {
+		return nil, unauthenticatedError(errors.New("user is closed"))
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, s.cfg.AccessTokenDuration, refreshPayload.AccessScopes()...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create access token: %s", err)
+	}
+
+	refreshToken, newRefreshPayload, err := s.tokenMaker.CreateToken(user.Username, user.Role, s.cfg.RefreshTokenDuration, append(refreshPayload.AccessScopes(), token.ScopeRefresh)...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create refresh token: %s", err)
+	}
+
+	mtdt := s.extractMetadata(ctx)
+	newSession, err := s.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		OldSessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:                   newRefreshPayload.ID,
+			Username:             session.Username,
+			RefreshToken:         refreshToken,
+			UserAgent:            mtdt.UserAgent,
+			ClientIp:             mtdt.ClientIP,
+			IsBlocked:            false,
+			ExpiresAt:            newRefreshPayload.ExpiredAt,
+			AccessTokenID:        uuid.NullUUID{UUID: accessPayload.ID, Valid: true},
+			AccessTokenExpiresAt: sql.NullTime{Time: accessPayload.ExpiredAt, Valid: true},
+		},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrSessionReused) {
+			return nil, s.blockSessionFamily(ctx, session.FamilyID)
+		}
+
+		return nil, status.Errorf(codes.Internal, "failed to rotate session: %s", err)
+	}
+
+	resp := &pb.RenewAccessTokenResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(newRefreshPayload.ExpiredAt),
+		SessionId:             newSession.ID.String(),
+	}
+
+	return resp, nil
+}
+
+// blockSessionFamily answers a refresh token reuse by blocking every session of its family
+func (s *Server) blockSessionFamily(ctx context.Context, familyID uuid.UUID) error {
+	sessions, err := s.store.BlockSessionFamily(ctx, familyID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to block sessions: %s", err)
+	}
+
+	err = s.revocations.RevokeSessions(ctx, sessions)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to revoke access tokens: %s", err)
+	}
+
+	return unauthenticatedError(db.ErrSessionReused)
+}
+
+func validateRenewAccessTokenRequest(req *pb.RenewAccessTokenRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if len(req.GetRefreshToken()) == 0 {
+		violations = append(violations, fieldViolation("refresh_token", errors.New("is required")))
+	}
+
+	return violations
+}
@@ -0,0 +1,75 @@
+package gapi
+
+import (
+	"fmt"
+	"github.com/thehaung/simplebank/config"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/lockout"
+	"github.com/thehaung/simplebank/notify"
+	"github.com/thehaung/simplebank/passwordpolicy"
+	"github.com/thehaung/simplebank/pb"
+	"github.com/thehaung/simplebank/revocation"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/hashutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"net"
+)
+
+// Server serves gRPC requests for our banking service
+type Server struct {
+	pb.UnimplementedSimpleBankServer
+	cfg         *config.Config
+	store       db.Store
+	tokenMaker  token.Maker
+	revocations *revocation.List
+	loginGuard  *lockout.Guard
+	notifier    notify.Notifier
+	hasher      hashutil.Hasher
+	passwords   *passwordpolicy.Policy
+}
+
+// NewGrpcServer creates a new gRPC server
+func NewGrpcServer(cfg *config.Config, store db.Store) (*Server, error) {
+	tokenMaker, err := token.NewMakerFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier, err := notify.NewNotifierFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher, err := hashutil.NewHasherFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		cfg:         cfg,
+		store:       store,
+		tokenMaker:  tokenMaker,
+		revocations: revocation.NewList(store, cfg.RevocationCacheTTL),
+		loginGuard:  lockout.NewGuardFromConfig(store, cfg),
+		notifier:    notifier,
+		hasher:      hasher,
+		passwords:   passwordpolicy.NewFromConfig(cfg),
+	}
+
+	return server, nil
+}
+
+// Start runs the gRPC server on a specific address
+func (s *Server) Start(address string) error {
+	grpcServer := grpc.NewServer()
+	pb.RegisterSimpleBankServer(grpcServer, s)
+	reflection.Register(grpcServer)
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("cannot create listener: %w", err)
+	}
+
+	return grpcServer.Serve(listener)
+}
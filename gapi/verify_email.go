@@ -0,0 +1,39 @@
+package gapi
+
+import (
+	"context"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/notify"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/url"
+	"strconv"
+)
+
+// sendVerifyEmail sends the link the user verifies its email with, the secret code is only known by the message
+func (s *Server) sendVerifyEmail(ctx context.Context, user db.User, verifyEmail db.VerifyEmail, secretCode string) error {
+	link := notify.Link(s.cfg.VerifyEmailURL, url.Values{
+		"email_id":    {strconv.FormatInt(verifyEmail.ID, 10)},
+		"secret_code": {secretCode},
+	})
+
+	return s.notifier.Notify(ctx, notify.VerifyEmailMessage(user.Username, user.FullName, verifyEmail.Email, link, verifyEmail.ExpiresAt))
+}
+
+// requireVerifiedEmail refuses the RPC until the user verified its email, when the config asks for it
+func (s *Server) requireVerifiedEmail(ctx context.Context, username string) error {
+	if !s.cfg.RequireVerifiedEmail {
+		return nil
+	}
+
+	user, err := s.store.GetUser(ctx, username)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	if !user.IsEmailVerified {
+		return status.Errorf(codes.FailedPrecondition, "email has to be verified first")
+	}
+
+	return nil
+}
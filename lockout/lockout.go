@@ -0,0 +1,177 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/thehaung/simplebank/config"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"math"
+	"time"
+)
+
+// Scopes failed logins are counted in
+const (
+	ScopeUsername = "username"
+	ScopeIP       = "ip"
+)
+
+var ErrIncorrectCredentials = errors.New("incorrect username or password")
+
+// LockedError is returned while logins are locked for the username or the client ip
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// Config holds the thresholds of the Guard
+type Config struct {
+	// MaxAttempts is the number of consecutive failures per username before it's locked
+	MaxAttempts int
+	// MaxAttemptsPerIP is the number of consecutive failures per client ip before it's locked,
+	// it's usually higher since many users can share an ip
+	MaxAttemptsPerIP int
+	// LockoutDuration is the first lockout, it doubles with every failure past the threshold
+	LockoutDuration time.Duration
+	MaxLockout      time.Duration
+	// AttemptWindow is how long failures are remembered without a new one
+	AttemptWindow time.Duration
+}
+
+// Guard slows down password guessing by locking logins after too many failures,
+// both per username to protect an account and per client ip to slow down attacks spread over many accounts
+type Guard struct {
+	store db.Store
+	cfg   Config
+}
+
+// NewGuard creates a new Guard backed by the store
+func NewGuard(store db.Store, cfg Config) *Guard {
+	return &Guard{
+		store: store,
+		cfg:   cfg,
+	}
+}
+
+// NewGuardFromConfig creates a new Guard with the thresholds of the application config
+func NewGuardFromConfig(store db.Store, cfg *config.Config) *Guard {
+	return NewGuard(store, Config{
+		MaxAttempts:      cfg.LoginMaxAttempts,
+		MaxAttemptsPerIP: cfg.LoginMaxAttemptsPerIP,
+		LockoutDuration:  cfg.LoginLockoutDuration,
+		MaxLockout:       cfg.LoginMaxLockout,
+		AttemptWindow:    cfg.LoginAttemptWindow,
+	})
+}
+
+// Check returns a *LockedError when logins are locked for the username or the client ip
+func (g *Guard) Check(ctx context.Context, username string, clientIP string) error {
+	for _, key := range g.keys(username, clientIP) {
+		throttle, err := g.store.GetLoginThrottle(ctx, key)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+
+			return err
+		}
+
+		if throttle.LockedUntil.Valid {
+			retryAfter := time.Until(throttle.LockedUntil.Time)
+			if retryAfter > 0 {
+				return &LockedError{RetryAfter: retryAfter}
+			}
+		}
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed login and locks the username or the client ip once over its threshold
+func (g *Guard) RecordFailure(ctx context.Context, username string, clientIP string) error {
+	for _, key := range g.keys(username, clientIP) {
+		throttle, err := g.store.RecordLoginFailure(ctx, db.RecordLoginFailureParams{
+			Scope:       key.Scope,
+			Key:         key.Key,
+			WindowStart: time.Now().Add(-g.cfg.AttemptWindow),
+		})
+		if err != nil {
+			return err
+		}
+
+		lockout := g.lockoutDuration(key.Scope, int(throttle.Failures))
+		if lockout == 0 {
+			continue
+		}
+
+		_, err = g.store.LockLogin(ctx, db.LockLoginParams{
+			Scope:       key.Scope,
+			Key:         key.Key,
+			LockedUntil: sql.NullTime{Time: time.Now().Add(lockout), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordSuccess forgets the failures of the username.
+// The client ip isn't reset, otherwise an attacker could clear it by logging into its own account.
+func (g *Guard) RecordSuccess(ctx context.Context, username string) error {
+	_, err := g.store.DeleteLoginThrottle(ctx, db.DeleteLoginThrottleParams{
+		Scope: ScopeUsername,
+		Key:   username,
+	})
+	return err
+}
+
+// Unlock lifts the lockout of the username, it returns false if it wasn't tracked
+func (g *Guard) Unlock(ctx context.Context, username string) (bool, error) {
+	deleted, err := g.store.DeleteLoginThrottle(ctx, db.DeleteLoginThrottleParams{
+		Scope: ScopeUsername,
+		Key:   username,
+	})
+	return deleted > 0, err
+}
+
+func (g *Guard) keys(username string, clientIP string) []db.GetLoginThrottleParams {
+	keys := []db.GetLoginThrottleParams{{Scope: ScopeUsername, Key: username}}
+	if len(clientIP) > 0 {
+		keys = append(keys, db.GetLoginThrottleParams{Scope: ScopeIP, Key: clientIP})
+	}
+
+	return keys
+}
+
+// lockoutDuration returns how long to lock after the provided number of consecutive failures, zero if not locked
+func (g *Guard) lockoutDuration(scope string, failures int) time.Duration {
+	maxAttempts := g.cfg.MaxAttempts
+	if scope == ScopeIP {
+		maxAttempts = g.cfg.MaxAttemptsPerIP
+	}
+
+	if maxAttempts <= 0 || failures < maxAttempts {
+		return 0
+	}
+
+	lockout := g.cfg.LockoutDuration
+	for i := maxAttempts; i < failures; i++ {
+		// without a cap the lockout stops doubling before it overflows and unlocks the account
+		if lockout > math.MaxInt64/2 {
+			return lockout
+		}
+
+		lockout *= 2
+		if g.cfg.MaxLockout > 0 && lockout >= g.cfg.MaxLockout {
+			return g.cfg.MaxLockout
+		}
+	}
+
+	return lockout
+}
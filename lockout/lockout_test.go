@@ -0,0 +1,135 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"testing"
+	"time"
+)
+
+var testConfig = Config{
+	MaxAttempts:      3,
+	MaxAttemptsPerIP: 10,
+	LockoutDuration:  time.Minute,
+	MaxLockout:       10 * time.Minute,
+	AttemptWindow:    15 * time.Minute,
+}
+
+func TestLockoutDuration(t *testing.T) {
+	guard := NewGuard(nil, testConfig)
+
+	testCases := []struct {
+		Scope    string
+		Failures int
+		Lockout  time.Duration
+	}{
+		{Scope: ScopeUsername, Failures: 1, Lockout: 0},
+		{Scope: ScopeUsername, Failures: 2, Lockout: 0},
+		{Scope: ScopeUsername, Failures: 3, Lockout: time.Minute},
+		{Scope: ScopeUsername, Failures: 4, Lockout: 2 * time.Minute},
+		{Scope: ScopeUsername, Failures: 5, Lockout: 4 * time.Minute},
+		{Scope: ScopeUsername, Failures: 6, Lockout: 8 * time.Minute},
+		{Scope: ScopeUsername, Failures: 7, Lockout: 10 * time.Minute},
+		{Scope: ScopeUsername, Failures: 100, Lockout: 10 * time.Minute},
+		{Scope: ScopeIP, Failures: 9, Lockout: 0},
+		{Scope: ScopeIP, Failures: 10, Lockout: time.Minute},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.Lockout, guard.lockoutDuration(tc.Scope, tc.Failures), "%s %d", tc.Scope, tc.Failures)
+	}
+}
+
+func TestLockoutDurationWithoutCap(t *testing.T) {
+	cfg := testConfig
+	cfg.MaxLockout = 0
+	guard := NewGuard(nil, cfg)
+
+	require.Equal(t, 8*time.Minute, guard.lockoutDuration(ScopeUsername, 6))
+
+	previous := time.Duration(0)
+	for _, failures := range []int{50, 100, 1000, 100000} {
+		lockout := guard.lockoutDuration(ScopeUsername, failures)
+		require.Positive(t, lockout, "%d", failures)
+		require.GreaterOrEqual(t, lockout, previous, "%d", failures)
+		previous = lockout
+	}
+}
+
+func TestCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	guard := NewGuard(store, testConfig)
+
+	// not tracked
+	store.EXPECT().
+		GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Scope: ScopeUsername, Key: "alice"})).
+		Times(1).
+		Return(db.LoginThrottle{}, sql.ErrNoRows)
+	store.EXPECT().
+		GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Scope: ScopeIP, Key: "10.0.0.1"})).
+		Times(1).
+		Return(db.LoginThrottle{Failures: 2}, nil)
+
+	err := guard.Check(context.Background(), "alice", "10.0.0.1")
+	require.NoError(t, err)
+
+	// locked ip
+	store.EXPECT().
+		GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Scope: ScopeUsername, Key: "bob"})).
+		Times(1).
+		Return(db.LoginThrottle{LockedUntil: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}}, nil)
+	store.EXPECT().
+		GetLoginThrottle(gomock.Any(), gomock.Eq(db.GetLoginThrottleParams{Scope: ScopeIP, Key: "10.0.0.2"})).
+		Times(1).
+		Return(db.LoginThrottle{LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}}, nil)
+
+	err = guard.Check(context.Background(), "bob", "10.0.0.2")
+
+	var lockedErr *LockedError
+	require.True(t, errors.As(err, &lockedErr))
+	require.InDelta(t, time.Minute, lockedErr.RetryAfter, float64(time.Second))
+}
+
+func TestRecordFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	guard := NewGuard(store, testConfig)
+
+	store.EXPECT().
+		RecordLoginFailure(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, arg db.RecordLoginFailureParams) (db.LoginThrottle, error) {
+			require.Equal(t, ScopeUsername, arg.Scope)
+			require.WithinDuration(t, time.Now().Add(-testConfig.AttemptWindow), arg.WindowStart, time.Second)
+			return db.LoginThrottle{Scope: arg.Scope, Key: arg.Key, Failures: 4}, nil
+		})
+	store.EXPECT().
+		RecordLoginFailure(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, arg db.RecordLoginFailureParams) (db.LoginThrottle, error) {
+			require.Equal(t, ScopeIP, arg.Scope)
+			return db.LoginThrottle{Scope: arg.Scope, Key: arg.Key, Failures: 4}, nil
+		})
+	store.EXPECT().
+		LockLogin(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, arg db.LockLoginParams) (db.LoginThrottle, error) {
+			require.Equal(t, ScopeUsername, arg.Scope)
+			require.Equal(t, "alice", arg.Key)
+			require.WithinDuration(t, time.Now().Add(2*time.Minute), arg.LockedUntil.Time, time.Second)
+			return db.LoginThrottle{}, nil
+		})
+
+	err := guard.RecordFailure(context.Background(), "alice", "10.0.0.1")
+	require.NoError(t, err)
+}
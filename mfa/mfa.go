@@ -0,0 +1,83 @@
+package mfa
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/util/totputil"
+	"time"
+)
+
+var (
+	ErrNotEnrolled = errors.New("two-factor authentication is not enabled")
+	ErrInvalidCode = errors.New("invalid two-factor code")
+)
+
+// IsEnabled check if the user confirmed a totp secret, login then asks for a code
+func IsEnabled(ctx context.Context, store db.Store, username string) (bool, error) {
+	secret, err := store.GetTotpSecret(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return secret.ConfirmedAt.Valid, nil
+}
+
+// Verify checks the second factor of the user, either a totp code or a recovery code.
+// Both are single-use: the time step of an accepted code is recorded and a recovery code is marked as used,
+// the conditional updates make concurrent attempts with the same code fail.
+func Verify(ctx context.Context, store db.Store, username string, code string, recoveryCode string) error {
+	secret, err := store.GetTotpSecret(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return ErrNotEnrolled
+		}
+
+		return err
+	}
+
+	if !secret.ConfirmedAt.Valid {
+		return ErrNotEnrolled
+	}
+
+	if len(recoveryCode) > 0 {
+		_, err = store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+			Username:   username,
+			HashedCode: totputil.HashRecoveryCode(recoveryCode),
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrInvalidCode
+			}
+
+			return err
+		}
+
+		return nil
+	}
+
+	step, ok := totputil.Validate(secret.Secret, code, time.Now())
+	if !ok {
+		return ErrInvalidCode
+	}
+
+	_, err = store.UseTotpStep(ctx, db.UseTotpStepParams{
+		Username: username,
+		Step:     step,
+	})
+	if err != nil {
+		// the code was already used
+		if err == sql.ErrNoRows {
+			return ErrInvalidCode
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Link adds the query parameters to the base url of a page the users are sent to
+func Link(baseURL string, params url.Values) string {
+	link, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL + "?" + params.Encode()
+	}
+
+	query := link.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	link.RawQuery = query.Encode()
+
+	return link.String()
+}
+
+// PasswordResetMessage is sent with the single-use link a user resets its password with
+func PasswordResetMessage(username, fullName, email, link string, expiresAt time.Time) Message {
+	return Message{
+		Username: username,
+		Email:    email,
+		Subject:  "Reset your simplebank password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nOpen the link below to choose a new password, it expires at %s:\n%s\n\nIgnore this message if you didn't ask for it.",
+			fullName, expiresAt.Format(time.RFC1123), link,
+		),
+	}
+}
+
+// VerifyEmailMessage is sent with the single-use link a user verifies its email with
+func VerifyEmailMessage(username, fullName, email, link string, expiresAt time.Time) Message {
+	return Message{
+		Username: username,
+		Email:    email,
+		Subject:  "Verify your simplebank email",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nOpen the link below to verify your email, it expires at %s:\n%s",
+			fullName, expiresAt.Format(time.RFC1123), link,
+		),
+	}
+}
@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"github.com/thehaung/simplebank/config"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Supported notifier types
+const (
+	LogNotifierType  = "log"
+	FileNotifierType = "file"
+)
+
+// Message is a notification sent to a user
+type Message struct {
+	Username string
+	Email    string
+	Subject  string
+	Body     string
+}
+
+// Notifier delivers messages to the users, e.g. by email
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// NewNotifierFromConfig creates the notifier selected in the application config
+func NewNotifierFromConfig(cfg *config.Config) (Notifier, error) {
+	switch cfg.NotifierType {
+	case "", LogNotifierType:
+		return NewLogNotifier(log.Default()), nil
+	case FileNotifierType:
+		return NewFileNotifier(cfg.NotifierDir)
+	}
+
+	return nil, fmt.Errorf("unsupported notifier type %s", cfg.NotifierType)
+}
+
+// LogNotifier writes the messages to a logger instead of delivering them, for local development
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogNotifier creates a new LogNotifier
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, msg Message) error {
+	n.logger.Printf("notify %s <%s>: %s\n%s", msg.Username, msg.Email, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileNotifier writes every message to its own file in a directory instead of delivering it,
+// for local development when the links in the messages have to be opened
+type FileNotifier struct {
+	dir string
+}
+
+// NewFileNotifier creates a new FileNotifier writing into the directory, which is created if missing
+func NewFileNotifier(dir string) (*FileNotifier, error) {
+	if len(dir) == 0 {
+		return nil, fmt.Errorf("the file notifier needs a directory")
+	}
+
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create notifier directory: %w", err)
+	}
+
+	return &FileNotifier{dir: dir}, nil
+}
+
+func (n *FileNotifier) Notify(_ context.Context, msg Message) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), msg.Username)
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "To: %s <%s>\r\n", msg.Username, msg.Email)
+	fmt.Fprintf(&content, "Subject: %s\r\n\r\n", msg.Subject)
+	content.WriteString(msg.Body)
+
+	return os.WriteFile(filepath.Join(n.dir, name), []byte(content.String()), 0o644)
+}
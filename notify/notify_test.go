@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/config"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	notifier := NewLogNotifier(log.New(&buf, "", 0))
+
+	err := notifier.Notify(context.Background(), Message{
+		Username: "alice",
+		Email:    "alice@example.com",
+		Subject:  "subject",
+		Body:     "body",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "notify alice <alice@example.com>: subject\nbody\n", buf.String())
+}
+
+func TestNewNotifierFromConfig(t *testing.T) {
+	notifier, err := NewNotifierFromConfig(&config.Config{})
+	require.NoError(t, err)
+	require.IsType(t, &LogNotifier{}, notifier)
+
+	_, err = NewNotifierFromConfig(&config.Config{NotifierType: "pigeon"})
+	require.Error(t, err)
+}
+
+func TestFileNotifier(t *testing.T) {
+	dir := t.TempDir()
+	notifier, err := NewFileNotifier(filepath.Join(dir, "mail"))
+	require.NoError(t, err)
+
+	err = notifier.Notify(context.Background(), Message{
+		Username: "alice",
+		Email:    "alice@example.com",
+		Subject:  "subject",
+		Body:     "body",
+	})
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(filepath.Join(dir, "mail"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	content, err := os.ReadFile(filepath.Join(dir, "mail", files[0].Name()))
+	require.NoError(t, err)
+	require.Equal(t, "To: alice <alice@example.com>\r\nSubject: subject\r\n\r\nbody", string(content))
+
+	_, err = NewFileNotifier("")
+	require.Error(t, err)
+}
+
+func TestLink(t *testing.T) {
+	link := Link("https://bank.example/reset?lang=en", url.Values{"token": {"a+b/c"}})
+
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	require.Equal(t, "en", parsed.Query().Get("lang"))
+	require.Equal(t, "a+b/c", parsed.Query().Get("token"))
+}
@@ -0,0 +1,221 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"github.com/thehaung/simplebank/config"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rules a password can break
+const (
+	RuleMinLength     = "min_length"
+	RuleMaxLength     = "max_length"
+	RuleUppercase     = "uppercase"
+	RuleLowercase     = "lowercase"
+	RuleDigit         = "digit"
+	RuleSymbol        = "symbol"
+	RuleMaxRepetition = "max_repetition"
+	RuleUserInfo      = "user_info"
+	RuleBreached      = "breached"
+)
+
+const (
+	_defaultMinLength = 8
+	_defaultMaxLength = 128
+	// user inputs shorter than this are too common to be looked for in the password
+	_minUserInputLength = 3
+)
+
+//go:embed breached.txt
+var _breachedList string
+
+// Config holds the rules of the Policy
+type Config struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// MaxRepetition is the longest run of the same character, zero means unlimited
+	MaxRepetition int
+	// CheckBreached rejects the passwords of the bundled list of commonly breached passwords
+	CheckBreached bool
+}
+
+// Violation is a rule the password breaks
+type Violation struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+}
+
+// Policy tells whether a password is strong enough to be set
+type Policy struct {
+	cfg      Config
+	breached map[string]struct{}
+}
+
+// New creates a new Policy, zero lengths fall back to the defaults
+func New(cfg Config) *Policy {
+	if cfg.MinLength <= 0 {
+		cfg.MinLength = _defaultMinLength
+	}
+	if cfg.MaxLength <= 0 {
+		cfg.MaxLength = _defaultMaxLength
+	}
+
+	p := &Policy{cfg: cfg}
+	if cfg.CheckBreached {
+		p.breached = parseBreachedList(_breachedList)
+	}
+
+	return p
+}
+
+// NewFromConfig creates a new Policy with the rules of the application config
+func NewFromConfig(cfg *config.Config) *Policy {
+	return New(Config{
+		MinLength:     cfg.PasswordMinLength,
+		MaxLength:     cfg.PasswordMaxLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+		MaxRepetition: cfg.PasswordMaxRepetition,
+		CheckBreached: cfg.PasswordCheckBreached,
+	})
+}
+
+// Validate returns every rule the password breaks, or nil if it's accepted.
+// The user inputs, e.g. the username and the email, must not be part of the password.
+func (p *Policy) Validate(password string, userInputs ...string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.MinLength {
+		violations = append(violations, Violation{
+			Rule:        RuleMinLength,
+			Description: fmt.Sprintf("must contain at least %d characters", p.cfg.MinLength),
+		})
+	}
+	if length > p.cfg.MaxLength {
+		violations = append(violations, Violation{
+			Rule:        RuleMaxLength,
+			Description: fmt.Sprintf("must contain at most %d characters", p.cfg.MaxLength),
+		})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.cfg.RequireUpper && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUppercase, Description: "must contain an uppercase letter"})
+	}
+	if p.cfg.RequireLower && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLowercase, Description: "must contain a lowercase letter"})
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: RuleDigit, Description: "must contain a digit"})
+	}
+	if p.cfg.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Rule: RuleSymbol, Description: "must contain a symbol"})
+	}
+
+	if p.cfg.MaxRepetition > 0 && longestRepetition(password) > p.cfg.MaxRepetition {
+		violations = append(violations, Violation{
+			Rule:        RuleMaxRepetition,
+			Description: fmt.Sprintf("must not repeat the same character more than %d times in a row", p.cfg.MaxRepetition),
+		})
+	}
+
+	if containsUserInput(password, userInputs) {
+		violations = append(violations, Violation{Rule: RuleUserInfo, Description: "must not contain the username or the email"})
+	}
+
+	if p.isBreached(password) {
+		violations = append(violations, Violation{Rule: RuleBreached, Description: "is too common, it appears in breached password lists"})
+	}
+
+	return violations
+}
+
+func (p *Policy) isBreached(password string) bool {
+	if p.breached == nil {
+		return false
+	}
+
+	_, found := p.breached[strings.ToLower(password)]
+	return found
+}
+
+func longestRepetition(password string) int {
+	longest, current := 0, 0
+	var previous rune
+
+	for i, r := range []rune(password) {
+		if i > 0 && r == previous {
+			current++
+		} else {
+			current = 1
+		}
+
+		if current > longest {
+			longest = current
+		}
+		previous = r
+	}
+
+	return longest
+}
+
+// containsUserInput looks for the inputs in the password, an email is also looked for without its domain
+func containsUserInput(password string, userInputs []string) bool {
+	lowered := strings.ToLower(password)
+
+	for _, input := range userInputs {
+		input = strings.ToLower(input)
+		candidates := []string{input}
+		if at := strings.LastIndex(input, "@"); at > 0 {
+			candidates = append(candidates, input[:at])
+		}
+
+		for _, candidate := range candidates {
+			if len(candidate) >= _minUserInputLength && strings.Contains(lowered, candidate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func parseBreachedList(list string) map[string]struct{} {
+	breached := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		breached[strings.ToLower(line)] = struct{}{}
+	}
+
+	return breached
+}
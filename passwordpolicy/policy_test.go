@@ -0,0 +1,119 @@
+package passwordpolicy
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/config"
+	"testing"
+)
+
+func rules(violations []Violation) []string {
+	var result []string
+	for _, violation := range violations {
+		result = append(result, violation.Rule)
+	}
+
+	return result
+}
+
+func TestValidate(t *testing.T) {
+	policy := New(Config{
+		MinLength:     8,
+		MaxLength:     20,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		MaxRepetition: 2,
+		CheckBreached: true,
+	})
+
+	testCases := []struct {
+		Name       string
+		Password   string
+		UserInputs []string
+		Rules      []string
+	}{
+		{
+			Name:     "OK",
+			Password: "Corr3ct-Horse",
+		},
+		{
+			Name:     "TooShort",
+			Password: "Ab1-",
+			Rules:    []string{RuleMinLength},
+		},
+		{
+			Name:     "TooLong",
+			Password: "Correct-Horse-Battery-Staple-1",
+			Rules:    []string{RuleMaxLength},
+		},
+		{
+			Name:     "MissingClasses",
+			Password: "correcthorse",
+			Rules:    []string{RuleUppercase, RuleDigit, RuleSymbol},
+		},
+		{
+			Name:     "Repetition",
+			Password: "Corr3ct-Horseee",
+			Rules:    []string{RuleMaxRepetition},
+		},
+		{
+			Name:       "ContainsUsername",
+			Password:   "Alice-2024!",
+			UserInputs: []string{"alice", "someone@example.com"},
+			Rules:      []string{RuleUserInfo},
+		},
+		{
+			Name:       "ContainsEmailLocalPart",
+			Password:   "Bob.Smith-99",
+			UserInputs: []string{"bobby", "bob.smith@example.com"},
+			Rules:      []string{RuleUserInfo},
+		},
+		{
+			Name:       "ShortUserInputsIgnored",
+			Password:   "Corr3ct-Horse",
+			UserInputs: []string{"co", "r@example.com"},
+		},
+		{
+			Name:     "Breached",
+			Password: "Password1",
+			Rules:    []string{RuleSymbol, RuleBreached},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			require.Equal(t, tc.Rules, rules(policy.Validate(tc.Password, tc.UserInputs...)))
+		})
+	}
+}
+
+func TestBreachedListDisabled(t *testing.T) {
+	policy := New(Config{})
+	require.Empty(t, policy.Validate("password123"))
+	require.Equal(t, []string{RuleMinLength}, rules(policy.Validate("123456")))
+}
+
+func TestBundledBreachedList(t *testing.T) {
+	breached := parseBreachedList(_breachedList)
+	require.Greater(t, len(breached), 100)
+
+	for password := range breached {
+		require.NotContains(t, password, "#")
+	}
+}
+
+func TestLongestRepetition(t *testing.T) {
+	require.Equal(t, 0, longestRepetition(""))
+	require.Equal(t, 1, longestRepetition("abc"))
+	require.Equal(t, 3, longestRepetition("abbbc"))
+	require.Equal(t, 4, longestRepetition("ééééa"))
+}
+
+func TestNewFromConfig(t *testing.T) {
+	policy := NewFromConfig(&config.Config{PasswordMinLength: 12, PasswordCheckBreached: true})
+	require.Equal(t, 12, policy.cfg.MinLength)
+	require.Equal(t, _defaultMaxLength, policy.cfg.MaxLength)
+	require.NotEmpty(t, policy.breached)
+}
@@ -0,0 +1,150 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"sync"
+	"time"
+)
+
+const _sweepInterval = time.Minute
+
+// List tells whether an access token has been revoked before it expired.
+// Revoked token ids are stored in Postgres so every instance sees them, and cached in memory until the token expires.
+// Tokens found not revoked are cached for negativeTTL, which bounds how long a revocation made by
+// another instance takes to apply here.
+type List struct {
+	store       db.Store
+	negativeTTL time.Duration
+
+	mu        sync.Mutex
+	entries   map[uuid.UUID]entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// NewList creates a new List backed by the store
+func NewList(store db.Store, negativeTTL time.Duration) *List {
+	return &List{
+		store:       store,
+		negativeTTL: negativeTTL,
+		entries:     make(map[uuid.UUID]entry),
+		lastSweep:   time.Now(),
+	}
+}
+
+// IsRevoked check if the token has been revoked
+func (l *List) IsRevoked(ctx context.Context, payload *token.Payload) (bool, error) {
+	if e, ok := l.get(payload.ID); ok {
+		return e.revoked, nil
+	}
+
+	_, err := l.store.GetRevokedToken(ctx, payload.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			expiresAt := time.Now().Add(l.negativeTTL)
+			if expiresAt.After(payload.ExpiredAt) {
+				expiresAt = payload.ExpiredAt
+			}
+
+			l.set(payload.ID, entry{revoked: false, expiresAt: expiresAt})
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	l.set(payload.ID, entry{revoked: true, expiresAt: payload.ExpiredAt})
+	return true, nil
+}
+
+// Revoke revokes the token with the provided id until it expires
+func (l *List) Revoke(ctx context.Context, id uuid.UUID, username string, expiresAt time.Time) error {
+	// an expired token is rejected anyway
+	if time.Now().After(expiresAt) {
+		return nil
+	}
+
+	err := l.store.RevokeToken(ctx, db.RevokeTokenParams{
+		ID:        id,
+		Username:  username,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	l.set(id, entry{revoked: true, expiresAt: expiresAt})
+	return nil
+}
+
+// RevokeSessions revokes the refresh tokens of the sessions and the access tokens issued together with them.
+// The refresh token is revoked too since the ones issued before the refresh marker pass as access tokens.
+func (l *List) RevokeSessions(ctx context.Context, sessions []db.Session) error {
+	for _, session := range sessions {
+		err := l.Revoke(ctx, session.ID, session.Username, session.ExpiresAt)
+		if err != nil {
+			return err
+		}
+
+		// sessions created before access tokens were tracked
+		if !session.AccessTokenID.Valid || !session.AccessTokenExpiresAt.Valid {
+			continue
+		}
+
+		err = l.Revoke(ctx, session.AccessTokenID.UUID, session.Username, session.AccessTokenExpiresAt.Time)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *List) get(id uuid.UUID) (entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[id]
+	if !ok {
+		return e, false
+	}
+
+	if time.Now().After(e.expiresAt) {
+		delete(l.entries, id)
+		return e, false
+	}
+
+	return e, true
+}
+
+func (l *List) set(id uuid.UUID, e entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// a revocation always wins over a cached negative lookup
+	if current, ok := l.entries[id]; ok && current.revoked && !e.revoked {
+		return
+	}
+
+	l.entries[id] = e
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) < _sweepInterval {
+		return
+	}
+
+	for key, cached := range l.entries {
+		if now.After(cached.expiresAt) {
+			delete(l.entries, key)
+		}
+	}
+	l.lastSweep = now
+}
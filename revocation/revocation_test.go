@@ -0,0 +1,181 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"github.com/thehaung/simplebank/token"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"testing"
+	"time"
+)
+
+func newPayload(t *testing.T, duration time.Duration) *token.Payload {
+	payload, err := token.NewPayload("alice", roleutil.Depositor, duration)
+	require.NoError(t, err)
+
+	return payload
+}
+
+func TestIsRevokedCachesNegativeLookup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	payload := newPayload(t, time.Minute)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetRevokedToken(gomock.Any(), gomock.Eq(payload.ID)).
+		Times(1).
+		Return(db.RevokedToken{}, sql.ErrNoRows)
+
+	list := NewList(store, time.Minute)
+	for i := 0; i < 3; i++ {
+		revoked, err := list.IsRevoked(context.Background(), payload)
+		require.NoError(t, err)
+		require.False(t, revoked)
+	}
+}
+
+func TestIsRevokedNegativeLookupExpires(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	payload := newPayload(t, time.Minute)
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().
+			GetRevokedToken(gomock.Any(), gomock.Eq(payload.ID)).
+			Times(1).
+			Return(db.RevokedToken{}, sql.ErrNoRows),
+		store.EXPECT().
+			GetRevokedToken(gomock.Any(), gomock.Eq(payload.ID)).
+			Times(1).
+			Return(db.RevokedToken{ID: payload.ID}, nil),
+	)
+
+	list := NewList(store, time.Millisecond)
+
+	revoked, err := list.IsRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	time.Sleep(5 * time.Millisecond)
+
+	revoked, err = list.IsRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestIsRevokedStoreError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	payload := newPayload(t, time.Minute)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetRevokedToken(gomock.Any(), gomock.Eq(payload.ID)).
+		Times(1).
+		Return(db.RevokedToken{}, sql.ErrConnDone)
+
+	list := NewList(store, time.Minute)
+
+	_, err := list.IsRevoked(context.Background(), payload)
+	require.True(t, errors.Is(err, sql.ErrConnDone))
+}
+
+func TestRevoke(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	payload := newPayload(t, time.Minute)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		RevokeToken(gomock.Any(), gomock.Eq(db.RevokeTokenParams{
+			ID:        payload.ID,
+			Username:  payload.Username,
+			ExpiresAt: payload.ExpiredAt,
+		})).
+		Times(1)
+	store.EXPECT().GetRevokedToken(gomock.Any(), gomock.Any()).Times(0)
+
+	list := NewList(store, time.Minute)
+
+	err := list.Revoke(context.Background(), payload.ID, payload.Username, payload.ExpiredAt)
+	require.NoError(t, err)
+
+	revoked, err := list.IsRevoked(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestRevokeExpiredToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	payload := newPayload(t, -time.Minute)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(0)
+
+	list := NewList(store, time.Minute)
+
+	err := list.Revoke(context.Background(), payload.ID, payload.Username, payload.ExpiredAt)
+	require.NoError(t, err)
+}
+
+func TestRevokeSessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	refreshPayload := newPayload(t, time.Hour)
+	accessPayload := newPayload(t, time.Minute)
+	legacyPayload := newPayload(t, time.Hour)
+
+	sessions := []db.Session{
+		{
+			ID:                   refreshPayload.ID,
+			Username:             refreshPayload.Username,
+			ExpiresAt:            refreshPayload.ExpiredAt,
+			AccessTokenID:        uuid.NullUUID{UUID: accessPayload.ID, Valid: true},
+			AccessTokenExpiresAt: sql.NullTime{Time: accessPayload.ExpiredAt, Valid: true},
+		},
+		// created before access tokens were tracked, its refresh token is revoked anyway
+		{
+			ID:        legacyPayload.ID,
+			Username:  legacyPayload.Username,
+			ExpiresAt: legacyPayload.ExpiredAt,
+		},
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	for _, payload := range []*token.Payload{refreshPayload, accessPayload, legacyPayload} {
+		store.EXPECT().
+			RevokeToken(gomock.Any(), gomock.Eq(db.RevokeTokenParams{
+				ID:        payload.ID,
+				Username:  payload.Username,
+				ExpiresAt: payload.ExpiredAt,
+			})).
+			Times(1)
+	}
+	store.EXPECT().GetRevokedToken(gomock.Any(), gomock.Any()).Times(0)
+
+	list := NewList(store, time.Minute)
+
+	err := list.RevokeSessions(context.Background(), sessions)
+	require.NoError(t, err)
+
+	for _, payload := range []*token.Payload{refreshPayload, accessPayload, legacyPayload} {
+		revoked, err := list.IsRevoked(context.Background(), payload)
+		require.NoError(t, err)
+		require.True(t, revoked)
+	}
+}
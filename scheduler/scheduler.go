@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"log"
+	"time"
+)
+
+// _defaultInterval is used when the configured interval is missing, a ticker can't run without a positive one
+const _defaultInterval = time.Minute
+
+// Scheduler periodically executes the scheduled transfers that are due
+type Scheduler struct {
+	store    db.Store
+	interval time.Duration
+}
+
+// NewScheduler creates a new Scheduler polling the store every interval, or every minute if it isn't positive
+func NewScheduler(store db.Store, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = _defaultInterval
+	}
+
+	return &Scheduler{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Start runs the scheduler until the context is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.runDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDue executes scheduled transfers one by one until none is due anymore
+func (s *Scheduler) runDue(ctx context.Context) int {
+	executed := 0
+
+	for ctx.Err() == nil {
+		run, err := s.store.RunScheduledTransferTx(ctx)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				log.Println("scheduler - store.RunScheduledTransferTx(). Error:", err)
+			}
+			return executed
+		}
+
+		executed++
+		if run.Status == db.ScheduledTransferRunFailed {
+			log.Printf("scheduler - scheduled transfer [%d] failed: %s", run.ScheduledTransferID, run.ErrorMessage)
+		}
+	}
+
+	return executed
+}
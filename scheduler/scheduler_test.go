@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/thehaung/simplebank/db/mock"
+	db "github.com/thehaung/simplebank/db/sqlc"
+	"testing"
+	"time"
+)
+
+func TestRunDue(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		BuildStubs func(store *mockdb.MockStore)
+		Expected   int
+	}{
+		{
+			Name: "NothingDue",
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RunScheduledTransferTx(gomock.Any()).
+					Times(1).
+					Return(db.ScheduledTransferRun{}, sql.ErrNoRows)
+			},
+			Expected: 0,
+		},
+		{
+			Name: "RunUntilNothingDue",
+			BuildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().
+						RunScheduledTransferTx(gomock.Any()).
+						Return(db.ScheduledTransferRun{Status: db.ScheduledTransferRunSucceeded}, nil),
+					store.EXPECT().
+						RunScheduledTransferTx(gomock.Any()).
+						Return(db.ScheduledTransferRun{Status: db.ScheduledTransferRunFailed}, nil),
+					store.EXPECT().
+						RunScheduledTransferTx(gomock.Any()).
+						Return(db.ScheduledTransferRun{}, sql.ErrNoRows),
+				)
+			},
+			Expected: 2,
+		},
+		{
+			Name: "StopOnError",
+			BuildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RunScheduledTransferTx(gomock.Any()).
+					Times(1).
+					Return(db.ScheduledTransferRun{}, sql.ErrConnDone)
+			},
+			Expected: 0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.BuildStubs(store)
+
+			scheduler := NewScheduler(store, time.Minute)
+			require.Equal(t, tc.Expected, scheduler.runDue(context.Background()))
+		})
+	}
+}
+
+func TestNewSchedulerInterval(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Interval time.Duration
+		Expected time.Duration
+	}{
+		{Name: "Configured", Interval: time.Second, Expected: time.Second},
+		{Name: "Missing", Interval: 0, Expected: _defaultInterval},
+		{Name: "Negative", Interval: -time.Second, Expected: _defaultInterval},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			s := NewScheduler(nil, tc.Interval)
+			require.Equal(t, tc.Expected, s.interval)
+		})
+	}
+}
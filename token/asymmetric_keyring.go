@@ -0,0 +1,129 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// AsymmetricKeyring holds the private key tokens are signed with and the public keys they are verified with.
+// Verifying only needs public keys, so keys of other services or previous private keys can be listed as PEM public keys.
+type AsymmetricKeyring struct {
+	activeKeyID string
+	privateKey  crypto.Signer
+	publicKeys  map[string]crypto.PublicKey
+}
+
+// NewAsymmetricKeyring creates a keyring signing with the private key of activeKeyID, keys are private or public keys
+func NewAsymmetricKeyring(activeKeyID string, keys map[string]interface{}, retiredKeyIDs []string) (*AsymmetricKeyring, error) {
+	keyring := &AsymmetricKeyring{
+		activeKeyID: activeKeyID,
+		publicKeys:  make(map[string]crypto.PublicKey, len(keys)),
+	}
+
+	for id, key := range keys {
+		switch k := key.(type) {
+		case crypto.Signer:
+			keyring.publicKeys[id] = k.Public()
+			if id == activeKeyID {
+				keyring.privateKey = k
+			}
+		case ed25519.PublicKey, *rsa.PublicKey:
+			keyring.publicKeys[id] = k
+		default:
+			return nil, fmt.Errorf("unsupported type %T of key %s", key, id)
+		}
+	}
+
+	for _, id := range retiredKeyIDs {
+		if id == activeKeyID {
+			return nil, fmt.Errorf("active key %s can't be retired", id)
+		}
+
+		delete(keyring.publicKeys, id)
+	}
+
+	if keyring.privateKey == nil {
+		return nil, fmt.Errorf("active key %s is not a private key of the keyring", activeKeyID)
+	}
+
+	return keyring, nil
+}
+
+// LoadAsymmetricKeyring creates a keyring from PEM files indexed by key id
+func LoadAsymmetricKeyring(activeKeyID string, keyFiles map[string]string, retiredKeyIDs []string) (*AsymmetricKeyring, error) {
+	keys := make(map[string]interface{}, len(keyFiles))
+
+	for id, path := range keyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key %s: %w", id, err)
+		}
+
+		keys[id], err = ParsePEMKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key %s: %w", id, err)
+		}
+	}
+
+	return NewAsymmetricKeyring(activeKeyID, keys, retiredKeyIDs)
+}
+
+// ParsePEMKey parses a PKCS #8 or PKCS #1 private key, or a PKIX public key
+func ParsePEMKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block type %s", block.Type)
+}
+
+// ActiveKey returns the private key new tokens are signed with
+func (k *AsymmetricKeyring) ActiveKey() (string, crypto.Signer) {
+	return k.activeKeyID, k.privateKey
+}
+
+// PublicKey returns the public key with the provided id, tokens without kid use DefaultKeyID
+func (k *AsymmetricKeyring) PublicKey(id string) (crypto.PublicKey, bool) {
+	if len(id) == 0 {
+		id = DefaultKeyID
+	}
+
+	key, ok := k.publicKeys[id]
+	return key, ok
+}
+
+// validateKeyType makes sure every key of the keyring suits the maker
+func (k *AsymmetricKeyring) validateKeyType(valid func(key crypto.PublicKey) bool, rule string) error {
+	for id, key := range k.publicKeys {
+		if !valid(key) {
+			return fmt.Errorf("invalid type of key %s: %s", id, rule)
+		}
+	}
+
+	return nil
+}
+
+func isEd25519Key(key crypto.PublicKey) bool {
+	_, ok := key.(ed25519.PublicKey)
+	return ok
+}
+
+func isRSAKey(key crypto.PublicKey) bool {
+	rsaKey, ok := key.(*rsa.PublicKey)
+	return ok && rsaKey.Size()*8 >= _minRSAKeyBits
+}
@@ -0,0 +1,203 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/util/randutil"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newEd25519Keyring(t *testing.T) *AsymmetricKeyring {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keyring, err := NewAsymmetricKeyring("ed-1", map[string]interface{}{"ed-1": privateKey}, nil)
+	require.NoError(t, err)
+
+	return keyring
+}
+
+func newRSAKeyring(t *testing.T) *AsymmetricKeyring {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	keyring, err := NewAsymmetricKeyring("rsa-1", map[string]interface{}{"rsa-1": privateKey}, nil)
+	require.NoError(t, err)
+
+	return keyring
+}
+
+func TestAsymmetricMakers(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		NewMaker func(t *testing.T) Maker
+	}{
+		{
+			Name: PasetoPublicMakerType,
+			NewMaker: func(t *testing.T) Maker {
+				maker, err := NewPasetoPublicMaker(newEd25519Keyring(t), ClaimsConfig{})
+				require.NoError(t, err)
+				return maker
+			},
+		},
+		{
+			Name: JwtEdDSAMakerType,
+			NewMaker: func(t *testing.T) Maker {
+				maker, err := NewJwtEdDSAMaker(newEd25519Keyring(t), ClaimsConfig{})
+				require.NoError(t, err)
+				return maker
+			},
+		},
+		{
+			Name: JwtRS256MakerType,
+			NewMaker: func(t *testing.T) Maker {
+				maker, err := NewJwtRS256Maker(newRSAKeyring(t), ClaimsConfig{})
+				require.NoError(t, err)
+				return maker
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			maker := tc.NewMaker(t)
+
+			userName := randutil.Owner()
+			token, payload, err := maker.CreateToken(userName, roleutil.Banker, time.Minute)
+			require.NoError(t, err)
+			require.NotEmpty(t, token)
+
+			verified, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, payload.ID, verified.ID)
+			require.Equal(t, userName, verified.Username)
+			require.Equal(t, roleutil.Banker, verified.Role)
+			require.WithinDuration(t, payload.ExpiredAt, verified.ExpiredAt, time.Second)
+
+			expiredToken, _, err := maker.CreateToken(userName, roleutil.Banker, -time.Minute)
+			require.NoError(t, err)
+
+			_, err = maker.VerifyToken(expiredToken)
+			require.EqualError(t, err, ErrExpiredToken.Error())
+
+			// a token of another key pair is rejected
+			otherToken, _, err := tc.NewMaker(t).CreateToken(userName, roleutil.Banker, time.Minute)
+			require.NoError(t, err)
+
+			_, err = maker.VerifyToken(otherToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+
+			publicKeys := maker.(PublicKeyMaker).PublicKeys()
+			require.Len(t, publicKeys.Keys, 1)
+			require.NotEmpty(t, publicKeys.Keys[0].KeyID)
+		})
+	}
+}
+
+func TestJwtAsymmetricMakerRejectsOtherAlgorithms(t *testing.T) {
+	keyring := newEd25519Keyring(t)
+	maker, err := NewJwtEdDSAMaker(keyring, ClaimsConfig{})
+	require.NoError(t, err)
+
+	payload, err := NewPayload(randutil.Owner(), roleutil.Admin, time.Minute)
+	require.NoError(t, err)
+
+	noneToken, err := jwt.NewWithClaims(jwt.SigningMethodNone, payload).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(noneToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	// HS256 signed with the public key must not pass for EdDSA
+	publicKey, _ := keyring.PublicKey("ed-1")
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	hmacToken.Header[_jwtKeyIDHeader] = "ed-1"
+	signed, err := hmacToken.SignedString([]byte(publicKey.(ed25519.PublicKey)))
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(signed)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestPasetoPublicMakerSpecVector(t *testing.T) {
+	// test vector 4-S-1 of the PASETO spec, its exp claim isn't ours so the payload looks expired
+	secretKey, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+
+	keyring, err := NewAsymmetricKeyring(DefaultKeyID, map[string]interface{}{DefaultKeyID: ed25519.PrivateKey(secretKey)}, nil)
+	require.NoError(t, err)
+
+	maker, err := NewPasetoPublicMaker(keyring, ClaimsConfig{})
+	require.NoError(t, err)
+
+	token := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+	_, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+
+	// flip a character of the signature
+	tampered := token[:len(token)-10] + "A" + token[len(token)-9:]
+	require.NotEqual(t, token, tampered)
+	_, err = maker.VerifyToken(tampered)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+}
+
+func TestPreAuthEncode(t *testing.T) {
+	require.Equal(t, []byte("\x00\x00\x00\x00\x00\x00\x00\x00"), preAuthEncode())
+	require.Equal(t, []byte("\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"), preAuthEncode([]byte("")))
+	require.Equal(t, []byte("\x01\x00\x00\x00\x00\x00\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00test"), preAuthEncode([]byte("test")))
+}
+
+func TestLoadAsymmetricKeyring(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	oldPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writePEM := func(name, blockType string, der []byte) string {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+		require.NoError(t, err)
+		return path
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	oldPublicDER, err := x509.MarshalPKIXPublicKey(oldPublicKey)
+	require.NoError(t, err)
+
+	keyFiles := map[string]string{
+		"new": writePEM("new.pem", "PRIVATE KEY", privateDER),
+		"old": writePEM("old.pub.pem", "PUBLIC KEY", oldPublicDER),
+	}
+
+	keyring, err := LoadAsymmetricKeyring("new", keyFiles, nil)
+	require.NoError(t, err)
+
+	key, ok := keyring.PublicKey("new")
+	require.True(t, ok)
+	require.Equal(t, publicKey, key)
+
+	key, ok = keyring.PublicKey("old")
+	require.True(t, ok)
+	require.Equal(t, oldPublicKey, key)
+
+	// a public key can't sign
+	_, err = LoadAsymmetricKeyring("old", keyFiles, nil)
+	require.Error(t, err)
+
+	// an Ed25519 keyring doesn't suit RS256
+	_, err = NewJwtRS256Maker(keyring, ClaimsConfig{})
+	require.Error(t, err)
+}
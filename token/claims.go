@@ -0,0 +1,60 @@
+package token
+
+// Scopes that can be granted to a token
+const (
+	ScopeAccountsRead   = "accounts:read"
+	ScopeAccountsWrite  = "accounts:write"
+	ScopeTransfersWrite = "transfers:write"
+)
+
+// ScopeMfaPending marks the short-lived token handed out by login when a second factor is still expected,
+// it can only be exchanged for real tokens and is never granted by IsSupportScope
+const ScopeMfaPending = "mfa:pending"
+
+// ScopeRefresh marks the refresh tokens, they can only be exchanged for new tokens
+// and are never accepted as an access token nor granted by IsSupportScope
+const ScopeRefresh = "refresh"
+
+// IsSupportScope check if the scope can be granted to a token
+func IsSupportScope(scope string) bool {
+	switch scope {
+	case ScopeAccountsRead, ScopeAccountsWrite, ScopeTransfersWrite:
+		return true
+	}
+
+	return false
+}
+
+// ClaimsConfig holds the issuer and audience a maker puts in every token and expects back when verifying.
+// An empty field is neither set nor checked.
+type ClaimsConfig struct {
+	Issuer   string
+	Audience string
+}
+
+// stamp sets the issuer and audience of a new token
+func (c ClaimsConfig) stamp(payload *Payload) {
+	payload.Issuer = c.Issuer
+	if len(c.Audience) > 0 {
+		payload.Audience = []string{c.Audience}
+	}
+}
+
+// verify makes sure the token was issued by and for us
+func (c ClaimsConfig) verify(payload *Payload) error {
+	if len(c.Issuer) > 0 && payload.Issuer != c.Issuer {
+		return ErrInvalidToken
+	}
+
+	if len(c.Audience) == 0 {
+		return nil
+	}
+
+	for _, audience := range payload.Audience {
+		if audience == c.Audience {
+			return nil
+		}
+	}
+
+	return ErrInvalidToken
+}
@@ -0,0 +1,109 @@
+package token
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/util/randutil"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"testing"
+	"time"
+)
+
+func TestMakerClaims(t *testing.T) {
+	keyring := NewSingleKeyring(randutil.StringWithQuantity(32))
+	claims := ClaimsConfig{Issuer: "simplebank", Audience: "simplebank-api"}
+
+	for _, makerType := range []string{JwtMakerType, PasetoMakerType} {
+		t.Run(makerType, func(t *testing.T) {
+			maker, err := NewMaker(makerType, keyring, claims)
+			require.NoError(t, err)
+
+			token, _, err := maker.CreateToken(randutil.Owner(), roleutil.Depositor, time.Minute, ScopeTransfersWrite)
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, claims.Issuer, payload.Issuer)
+			require.Equal(t, []string{claims.Audience}, payload.Audience)
+			require.Equal(t, []string{ScopeTransfersWrite}, payload.Scopes)
+			require.WithinDuration(t, time.Now(), payload.NotBefore, time.Second)
+
+			// same key but another issuer
+			otherIssuer, err := NewMaker(makerType, keyring, ClaimsConfig{Issuer: "other", Audience: claims.Audience})
+			require.NoError(t, err)
+
+			_, err = otherIssuer.VerifyToken(token)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+
+			// same key but meant for another audience
+			otherAudience, err := NewMaker(makerType, keyring, ClaimsConfig{Issuer: claims.Issuer, Audience: "other"})
+			require.NoError(t, err)
+
+			_, err = otherAudience.VerifyToken(token)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+		})
+	}
+}
+
+func TestPayloadNotBefore(t *testing.T) {
+	payload, err := NewPayload(randutil.Owner(), roleutil.Depositor, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, payload.Valid())
+
+	// the clock of the verifying instance may be slightly behind
+	payload.NotBefore = time.Now().Add(10 * time.Second)
+	require.NoError(t, payload.Valid())
+
+	payload.NotBefore = time.Now().Add(time.Minute)
+	require.EqualError(t, payload.Valid(), ErrInvalidToken.Error())
+}
+
+func TestPayloadNumericDates(t *testing.T) {
+	payload, err := NewPayload(randutil.Owner(), roleutil.Depositor, time.Hour)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	var claims map[string]interface{}
+	err = json.Unmarshal(data, &claims)
+	require.NoError(t, err)
+	require.Equal(t, float64(payload.IssuedAt.Unix()), claims["iat"])
+	require.Equal(t, float64(payload.NotBefore.Unix()), claims["nbf"])
+	require.Equal(t, float64(payload.ExpiredAt.Unix()), claims["exp"])
+
+	var decoded Payload
+	err = json.Unmarshal(data, &decoded)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, decoded.ID)
+	require.Equal(t, payload.NotBefore.Unix(), decoded.NotBefore.Unix())
+	require.WithinDuration(t, payload.ExpiredAt, decoded.ExpiredAt, 0)
+
+	// tokens issued before nbf was a NumericDate
+	err = json.Unmarshal([]byte(`{"nbf":"2023-01-15T09:00:00Z"}`), &decoded)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 1, 15, 9, 0, 0, 0, time.UTC), decoded.NotBefore.UTC())
+}
+
+func TestPayloadHasScope(t *testing.T) {
+	unrestricted, err := NewPayload(randutil.Owner(), roleutil.Depositor, time.Minute)
+	require.NoError(t, err)
+	require.True(t, unrestricted.HasScope(ScopeTransfersWrite))
+
+	scoped, err := NewPayload(randutil.Owner(), roleutil.Depositor, time.Minute, ScopeAccountsRead)
+	require.NoError(t, err)
+	require.True(t, scoped.HasScope(ScopeAccountsRead))
+	require.False(t, scoped.HasScope(ScopeTransfersWrite))
+}
+
+func TestPayloadRefresh(t *testing.T) {
+	access, err := NewPayload(randutil.Owner(), roleutil.Depositor, time.Minute, ScopeAccountsRead)
+	require.NoError(t, err)
+	require.False(t, access.IsRefresh())
+
+	refresh, err := NewPayload(randutil.Owner(), roleutil.Depositor, time.Minute, ScopeAccountsRead, ScopeRefresh)
+	require.NoError(t, err)
+	require.True(t, refresh.IsRefresh())
+	require.Equal(t, []string{ScopeAccountsRead}, refresh.AccessScopes())
+	require.False(t, IsSupportScope(ScopeRefresh))
+}
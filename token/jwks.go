@@ -0,0 +1,66 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+)
+
+// JSONWebKey is a public key in the JWK format (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+}
+
+// JSONWebKeySet is the document served by a JWKS endpoint
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// PublicKeyMaker is implemented by the makers signing with asymmetric keys, so their public keys can be published
+type PublicKeyMaker interface {
+	Maker
+
+	// PublicKeys returns the keys verifying the tokens of the maker
+	PublicKeys() JSONWebKeySet
+}
+
+// jwks lists the public keys of the keyring ordered by id
+func (k *AsymmetricKeyring) jwks(algorithm string) JSONWebKeySet {
+	keySet := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(k.publicKeys))}
+
+	for id, key := range k.publicKeys {
+		jwk := JSONWebKey{
+			KeyID:     id,
+			Use:       "sig",
+			Algorithm: algorithm,
+		}
+
+		switch publicKey := key.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		}
+
+		keySet.Keys = append(keySet.Keys, jwk)
+	}
+
+	sort.Slice(keySet.Keys, func(i, j int) bool {
+		return keySet.Keys[i].KeyID < keySet.Keys[j].KeyID
+	})
+
+	return keySet
+}
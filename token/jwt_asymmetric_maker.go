@@ -0,0 +1,100 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"time"
+)
+
+const _minRSAKeyBits = 2048
+
+// JwtAsymmetricMaker is a JSON Web Token maker signing with a private key,
+// anyone holding the public key can verify its tokens
+type JwtAsymmetricMaker struct {
+	method  jwt.SigningMethod
+	keyring *AsymmetricKeyring
+	claims  ClaimsConfig
+}
+
+// NewJwtEdDSAMaker creates a new JwtAsymmetricMaker signing with Ed25519 keys
+func NewJwtEdDSAMaker(keyring *AsymmetricKeyring, claims ClaimsConfig) (Maker, error) {
+	err := keyring.validateKeyType(isEd25519Key, "must be an Ed25519 key")
+	if err != nil {
+		return nil, err
+	}
+
+	return &JwtAsymmetricMaker{method: SigningMethodEdDSA, keyring: keyring, claims: claims}, nil
+}
+
+// NewJwtRS256Maker creates a new JwtAsymmetricMaker signing with RSA keys
+func NewJwtRS256Maker(keyring *AsymmetricKeyring, claims ClaimsConfig) (Maker, error) {
+	err := keyring.validateKeyType(isRSAKey, fmt.Sprintf("must be an RSA key of at least %d bits", _minRSAKeyBits))
+	if err != nil {
+		return nil, err
+	}
+
+	return &JwtAsymmetricMaker{method: jwt.SigningMethodRS256, keyring: keyring, claims: claims}, nil
+}
+
+// CreateToken creates a new token for a specific username, role and duration, optionally narrowed to some scopes
+func (j *JwtAsymmetricMaker) CreateToken(username string, role string, duration time.Duration, scopes ...string) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration, scopes...)
+	if err != nil {
+		return "", payload, err
+	}
+	j.claims.stamp(payload)
+
+	keyID, privateKey := j.keyring.ActiveKey()
+
+	jwtToken := jwt.NewWithClaims(j.method, payload)
+	jwtToken.Header[_jwtKeyIDHeader] = keyID
+
+	token, err := jwtToken.SignedString(privateKey)
+	return token, payload, err
+}
+
+// VerifyToken check if provided token is valid or not
+func (j *JwtAsymmetricMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
+		// only the configured algorithm is accepted, never what the token header asks for
+		if t.Method.Alg() != j.method.Alg() {
+			return nil, ErrInvalidToken
+		}
+
+		keyID, _ := t.Header[_jwtKeyIDHeader].(string)
+		publicKey, ok := j.keyring.PublicKey(keyID)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		return publicKey, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		vErr, ok := err.(*jwt.ValidationError)
+		if ok && errors.Is(vErr.Inner, ErrExpiredToken) {
+			return nil, ErrExpiredToken
+		}
+
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	err = j.claims.verify(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// PublicKeys returns the keys verifying the tokens of the maker
+func (j *JwtAsymmetricMaker) PublicKeys() JSONWebKeySet {
+	return j.keyring.jwks(j.method.Alg())
+}
@@ -7,32 +7,48 @@ import (
 	"time"
 )
 
-const _minSecretKeySize = 32
+const (
+	_minSecretKeySize = 32
+	_jwtKeyIDHeader   = "kid"
+)
 
 // JwtMaker is a JSON Web Token maker
 type JwtMaker struct {
-	secretKey string
+	keyring *Keyring
+	claims  ClaimsConfig
 }
 
+// NewJwtMaker creates a new JwtMaker with a single secret key
 func NewJwtMaker(secretKey string) (Maker, error) {
-	if len(secretKey) < _minSecretKeySize {
-		return nil, fmt.Errorf("invalid key size: must be at least %d character", _minSecretKeySize)
+	return NewJwtMakerWithKeyring(NewSingleKeyring(secretKey), ClaimsConfig{})
+}
+
+// NewJwtMakerWithKeyring creates a new JwtMaker signing with the active key of the keyring
+func NewJwtMakerWithKeyring(keyring *Keyring, claims ClaimsConfig) (Maker, error) {
+	err := keyring.validateKeySize(func(key []byte) bool {
+		return len(key) >= _minSecretKeySize
+	}, fmt.Sprintf("must be at least %d character", _minSecretKeySize))
+	if err != nil {
+		return nil, err
 	}
 
-	return &JwtMaker{secretKey}, nil
+	return &JwtMaker{keyring: keyring, claims: claims}, nil
 }
 
-// CreateToken creates a new token for a specific username and duration
-func (j *JwtMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, duration)
+// CreateToken creates a new token for a specific username, role and duration, optionally narrowed to some scopes
+func (j *JwtMaker) CreateToken(username string, role string, duration time.Duration, scopes ...string) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration, scopes...)
 	if err != nil {
 		return "", payload, err
 	}
+	j.claims.stamp(payload)
+
+	keyID, secretKey := j.keyring.ActiveKey()
 
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-	secretKeyByte := []byte(j.secretKey)
+	jwtToken.Header[_jwtKeyIDHeader] = keyID
 
-	token, err := jwtToken.SignedString(secretKeyByte)
+	token, err := jwtToken.SignedString(secretKey)
 	return token, payload, err
 }
 
@@ -44,7 +60,13 @@ func (j *JwtMaker) VerifyToken(token string) (*Payload, error) {
 			return nil, ErrInvalidToken
 		}
 
-		return []byte(j.secretKey), nil
+		keyID, _ := t.Header[_jwtKeyIDHeader].(string)
+		secretKey, ok := j.keyring.Key(keyID)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		return secretKey, nil
 	}
 
 	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
@@ -62,5 +84,10 @@ func (j *JwtMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, ErrInvalidToken
 	}
 
+	err = j.claims.verify(payload)
+	if err != nil {
+		return nil, err
+	}
+
 	return payload, nil
 }
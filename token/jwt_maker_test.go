@@ -4,6 +4,7 @@ import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/stretchr/testify/require"
 	"github.com/thehaung/simplebank/util/randutil"
+	"github.com/thehaung/simplebank/util/roleutil"
 	"testing"
 	"time"
 )
@@ -13,12 +14,13 @@ func TestJwtMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	userName := randutil.Owner()
+	role := roleutil.Depositor
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, payload, err := maker.CreateToken(userName, duration)
+	token, payload, err := maker.CreateToken(userName, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -29,6 +31,7 @@ func TestJwtMaker(t *testing.T) {
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, userName, payload.Username)
+	require.Equal(t, role, payload.Role)
 
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
@@ -38,7 +41,7 @@ func TestExpiredJwtToken(t *testing.T) {
 	maker, err := NewJwtMaker(randutil.StringWithQuantity(32))
 	require.NoError(t, err)
 
-	token, payload, err := maker.CreateToken(randutil.Owner(), -time.Minute)
+	token, payload, err := maker.CreateToken(randutil.Owner(), roleutil.Depositor, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -50,7 +53,7 @@ func TestExpiredJwtToken(t *testing.T) {
 }
 
 func TestInvalidJwtTokenAlgNone(t *testing.T) {
-	payload, err := NewPayload(randutil.Owner(), time.Minute)
+	payload, err := NewPayload(randutil.Owner(), roleutil.Depositor, time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
@@ -68,7 +71,7 @@ func TestInvalidJwtTokenAlgNone(t *testing.T) {
 }
 
 func TestInvalidSecretKeySize(t *testing.T) {
-	payload, err := NewPayload(randutil.Owner(), time.Minute)
+	payload, err := NewPayload(randutil.Owner(), roleutil.Depositor, time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
@@ -0,0 +1,99 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultKeyID identifies the key of tokens issued before key IDs existed, they carry no kid
+const DefaultKeyID = "default"
+
+// Keyring holds the keys a maker signs and verifies tokens with.
+// Tokens are always signed with the active key and verified with whichever key their kid points to,
+// so a new key can be activated while tokens signed with the previous one stay valid until they expire.
+type Keyring struct {
+	activeKeyID string
+	keys        map[string][]byte
+}
+
+// NewKeyring creates a keyring signing with activeKeyID.
+// Retired keys are dropped, tokens signed with them are rejected right away.
+func NewKeyring(activeKeyID string, keys map[string]string, retiredKeyIDs []string) (*Keyring, error) {
+	keyring := &Keyring{
+		activeKeyID: activeKeyID,
+		keys:        make(map[string][]byte, len(keys)),
+	}
+
+	for id, secret := range keys {
+		keyring.keys[id] = []byte(secret)
+	}
+
+	for _, id := range retiredKeyIDs {
+		if id == activeKeyID {
+			return nil, fmt.Errorf("active key %s can't be retired", id)
+		}
+
+		delete(keyring.keys, id)
+	}
+
+	if _, ok := keyring.keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active key %s is not in the keyring", activeKeyID)
+	}
+
+	return keyring, nil
+}
+
+// NewSingleKeyring creates a keyring holding only one key under DefaultKeyID
+func NewSingleKeyring(secretKey string) *Keyring {
+	return &Keyring{
+		activeKeyID: DefaultKeyID,
+		keys:        map[string][]byte{DefaultKeyID: []byte(secretKey)},
+	}
+}
+
+// ParseKeys parses keys written as id:secret, the secret may itself contain colons.
+// It also parses the id:path entries of PEM key files.
+func ParseKeys(entries []string) (map[string]string, error) {
+	keys := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		id, secret, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || len(id) == 0 || len(secret) == 0 {
+			return nil, fmt.Errorf("invalid key entry %q: must be id:secret", entry)
+		}
+
+		if _, ok := keys[id]; ok {
+			return nil, fmt.Errorf("duplicated key id %s", id)
+		}
+
+		keys[id] = secret
+	}
+
+	return keys, nil
+}
+
+// ActiveKey returns the key new tokens are signed with
+func (k *Keyring) ActiveKey() (string, []byte) {
+	return k.activeKeyID, k.keys[k.activeKeyID]
+}
+
+// Key returns the key with the provided id, tokens without kid use DefaultKeyID
+func (k *Keyring) Key(id string) ([]byte, bool) {
+	if len(id) == 0 {
+		id = DefaultKeyID
+	}
+
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+// validateKeySize makes sure every key of the keyring suits the maker
+func (k *Keyring) validateKeySize(valid func(key []byte) bool, rule string) error {
+	for id, key := range k.keys {
+		if !valid(key) {
+			return fmt.Errorf("invalid size of key %s: %s", id, rule)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package token
+
+import (
+	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/util/randutil"
+	"github.com/thehaung/simplebank/util/roleutil"
+	"testing"
+	"time"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys, err := ParseKeys([]string{"2023-01:secret", " 2023-02:with:colon "})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"2023-01": "secret", "2023-02": "with:colon"}, keys)
+
+	_, err = ParseKeys([]string{"missing-secret"})
+	require.Error(t, err)
+
+	_, err = ParseKeys([]string{"a:secret", "a:other"})
+	require.Error(t, err)
+}
+
+func TestNewKeyring(t *testing.T) {
+	keys := map[string]string{"old": "old-secret", "new": "new-secret"}
+
+	_, err := NewKeyring("unknown", keys, nil)
+	require.Error(t, err)
+
+	_, err = NewKeyring("new", keys, []string{"new"})
+	require.Error(t, err)
+
+	keyring, err := NewKeyring("new", keys, []string{"old"})
+	require.NoError(t, err)
+
+	id, key := keyring.ActiveKey()
+	require.Equal(t, "new", id)
+	require.Equal(t, []byte("new-secret"), key)
+
+	_, ok := keyring.Key("old")
+	require.False(t, ok)
+}
+
+func TestKeyRotation(t *testing.T) {
+	makers := map[string]int{
+		JwtMakerType:    40,
+		PasetoMakerType: 32,
+	}
+
+	for makerType, keySize := range makers {
+		t.Run(makerType, func(t *testing.T) {
+			keys := map[string]string{
+				"old": randutil.StringWithQuantity(keySize),
+				"new": randutil.StringWithQuantity(keySize),
+			}
+
+			oldKeyring, err := NewKeyring("old", map[string]string{"old": keys["old"]}, nil)
+			require.NoError(t, err)
+			oldMaker, err := NewMaker(makerType, oldKeyring, ClaimsConfig{})
+			require.NoError(t, err)
+
+			token, _, err := oldMaker.CreateToken(randutil.Owner(), roleutil.Depositor, time.Minute)
+			require.NoError(t, err)
+
+			// the new key is active and the old one still verifies
+			rotatedKeyring, err := NewKeyring("new", keys, nil)
+			require.NoError(t, err)
+			rotatedMaker, err := NewMaker(makerType, rotatedKeyring, ClaimsConfig{})
+			require.NoError(t, err)
+
+			_, err = rotatedMaker.VerifyToken(token)
+			require.NoError(t, err)
+
+			newToken, _, err := rotatedMaker.CreateToken(randutil.Owner(), roleutil.Depositor, time.Minute)
+			require.NoError(t, err)
+
+			_, err = oldMaker.VerifyToken(newToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+
+			// once retired the old key doesn't verify anymore
+			retiredKeyring, err := NewKeyring("new", keys, []string{"old"})
+			require.NoError(t, err)
+			retiredMaker, err := NewMaker(makerType, retiredKeyring, ClaimsConfig{})
+			require.NoError(t, err)
+
+			_, err = retiredMaker.VerifyToken(token)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+
+			_, err = retiredMaker.VerifyToken(newToken)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestNewMakerUnsupportedType(t *testing.T) {
+	_, err := NewMaker("unknown", NewSingleKeyring(randutil.StringWithQuantity(32)), ClaimsConfig{})
+	require.Error(t, err)
+}
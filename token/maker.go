@@ -1,12 +1,92 @@
 package token
 
-import "time"
+import (
+	"fmt"
+	"github.com/thehaung/simplebank/config"
+	"time"
+)
+
+// Maker types that can be selected in config
+const (
+	JwtMakerType          = "jwt"
+	PasetoMakerType       = "paseto"
+	JwtEdDSAMakerType     = "jwt_eddsa"
+	JwtRS256MakerType     = "jwt_rs256"
+	PasetoPublicMakerType = "paseto_public"
+)
 
 type Maker interface {
 
-	// CreateToken creates a new token for a specific username and duration
-	CreateToken(username string, duration time.Duration) (string, *Payload, error)
+	// CreateToken creates a new token for a specific username, role and duration, optionally narrowed to some scopes
+	CreateToken(username string, role string, duration time.Duration, scopes ...string) (string, *Payload, error)
 
 	// VerifyToken check if provided token is valid or not
 	VerifyToken(token string) (*Payload, error)
 }
+
+// NewMaker creates a maker of the provided type using the keys of the keyring
+func NewMaker(makerType string, keyring *Keyring, claims ClaimsConfig) (Maker, error) {
+	switch makerType {
+	case JwtMakerType, "":
+		return NewJwtMakerWithKeyring(keyring, claims)
+	case PasetoMakerType:
+		return NewPasetoMakerWithKeyring(keyring, claims)
+	}
+
+	return nil, fmt.Errorf("unsupported token maker type %s", makerType)
+}
+
+// NewAsymmetricMaker creates a maker of the provided type using the key pairs of the keyring
+func NewAsymmetricMaker(makerType string, keyring *AsymmetricKeyring, claims ClaimsConfig) (Maker, error) {
+	switch makerType {
+	case JwtEdDSAMakerType:
+		return NewJwtEdDSAMaker(keyring, claims)
+	case JwtRS256MakerType:
+		return NewJwtRS256Maker(keyring, claims)
+	case PasetoPublicMakerType:
+		return NewPasetoPublicMaker(keyring, claims)
+	}
+
+	return nil, fmt.Errorf("unsupported asymmetric token maker type %s", makerType)
+}
+
+// NewMakerFromConfig creates the maker selected in config.
+// Asymmetric makers load their keys from the PEM files of TokenKeyFiles, symmetric makers use TokenKeys
+// and fall back to TokenSymmetricKey as the only key when none is configured.
+func NewMakerFromConfig(cfg *config.Config) (Maker, error) {
+	claims := ClaimsConfig{
+		Issuer:   cfg.TokenIssuer,
+		Audience: cfg.TokenAudience,
+	}
+
+	switch cfg.TokenMakerType {
+	case JwtEdDSAMakerType, JwtRS256MakerType, PasetoPublicMakerType:
+		keyFiles, err := ParseKeys(cfg.TokenKeyFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		keyring, err := LoadAsymmetricKeyring(cfg.TokenActiveKeyID, keyFiles, cfg.TokenRetiredKeyIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		return NewAsymmetricMaker(cfg.TokenMakerType, keyring, claims)
+	}
+
+	if len(cfg.TokenKeys) == 0 {
+		return NewMaker(cfg.TokenMakerType, NewSingleKeyring(cfg.TokenSymmetricKey), claims)
+	}
+
+	keys, err := ParseKeys(cfg.TokenKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring, err := NewKeyring(cfg.TokenActiveKeyID, keys, cfg.TokenRetiredKeyIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMaker(cfg.TokenMakerType, keyring, claims)
+}
@@ -10,40 +10,68 @@ import (
 
 // PasetoMaker is a PASETO token maker
 type PasetoMaker struct {
-	paseto       *paseto.V2
-	symmetricKey []byte
+	paseto  *paseto.V2
+	keyring *Keyring
+	claims  ClaimsConfig
 }
 
-// NewPasetoMaker creates a new PasetoMaker
+// pasetoFooter is sent in clear next to the encrypted payload to tell which key decrypts it
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// NewPasetoMaker creates a new PasetoMaker with a single symmetric key
 func NewPasetoMaker(symmetricKey string) (Maker, error) {
-	if len(symmetricKey) != chacha20poly1305.KeySize {
-		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+	return NewPasetoMakerWithKeyring(NewSingleKeyring(symmetricKey), ClaimsConfig{})
+}
+
+// NewPasetoMakerWithKeyring creates a new PasetoMaker encrypting with the active key of the keyring
+func NewPasetoMakerWithKeyring(keyring *Keyring, claims ClaimsConfig) (Maker, error) {
+	err := keyring.validateKeySize(func(key []byte) bool {
+		return len(key) == chacha20poly1305.KeySize
+	}, fmt.Sprintf("must be exactly %d characters", chacha20poly1305.KeySize))
+	if err != nil {
+		return nil, err
 	}
 
 	maker := &PasetoMaker{
-		paseto:       paseto.NewV2(),
-		symmetricKey: []byte(symmetricKey),
+		paseto:  paseto.NewV2(),
+		keyring: keyring,
+		claims:  claims,
 	}
 
 	return maker, nil
 }
 
-// CreateToken creates a new token for a specific username and duration
-func (m *PasetoMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, duration)
+// CreateToken creates a new token for a specific username, role and duration, optionally narrowed to some scopes
+func (m *PasetoMaker) CreateToken(username string, role string, duration time.Duration, scopes ...string) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration, scopes...)
 	if err != nil {
 		return "", payload, err
 	}
+	m.claims.stamp(payload)
+
+	keyID, symmetricKey := m.keyring.ActiveKey()
 
-	token, err := m.paseto.Encrypt(m.symmetricKey, payload, nil)
+	token, err := m.paseto.Encrypt(symmetricKey, payload, pasetoFooter{KeyID: keyID})
 	return token, payload, err
 }
 
 // VerifyToken checks if the token is valid or not
 func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
-	payload := &Payload{}
+	var footer pasetoFooter
+	err := paseto.ParseFooter(token, &footer)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
 
-	err := m.paseto.Decrypt(token, m.symmetricKey, payload, nil)
+	symmetricKey, ok := m.keyring.Key(footer.KeyID)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	err = m.paseto.Decrypt(token, symmetricKey, payload, nil)
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
@@ -53,5 +81,10 @@ func (m *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, err
 	}
 
+	err = m.claims.verify(payload)
+	if err != nil {
+		return nil, err
+	}
+
 	return payload, nil
 }
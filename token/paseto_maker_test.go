@@ -3,6 +3,7 @@ package token
 import (
 	"github.com/stretchr/testify/require"
 	"github.com/thehaung/simplebank/util/randutil"
+	"github.com/thehaung/simplebank/util/roleutil"
 	"testing"
 	"time"
 )
@@ -12,12 +13,13 @@ func TestPasetoMaker(t *testing.T) {
 	require.NoError(t, err)
 
 	userName := randutil.Owner()
+	role := roleutil.Depositor
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, payload, err := maker.CreateToken(userName, duration)
+	token, payload, err := maker.CreateToken(userName, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -28,6 +30,7 @@ func TestPasetoMaker(t *testing.T) {
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, userName, payload.Username)
+	require.Equal(t, role, payload.Role)
 
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
@@ -37,7 +40,7 @@ func TestExpiredPasetoToken(t *testing.T) {
 	maker, err := NewPasetoMaker(randutil.StringWithQuantity(32))
 	require.NoError(t, err)
 
-	token, payload, err := maker.CreateToken(randutil.Owner(), -time.Minute)
+	token, payload, err := maker.CreateToken(randutil.Owner(), roleutil.Depositor, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -0,0 +1,132 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const _pasetoV4PublicHeader = "v4.public."
+
+// PasetoPublicMaker is a PASETO v4.public token maker, tokens are signed with Ed25519 keys and not encrypted
+type PasetoPublicMaker struct {
+	keyring *AsymmetricKeyring
+	claims  ClaimsConfig
+}
+
+// NewPasetoPublicMaker creates a new PasetoPublicMaker
+func NewPasetoPublicMaker(keyring *AsymmetricKeyring, claims ClaimsConfig) (Maker, error) {
+	err := keyring.validateKeyType(isEd25519Key, "must be an Ed25519 key")
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasetoPublicMaker{keyring: keyring, claims: claims}, nil
+}
+
+// CreateToken creates a new token for a specific username, role and duration, optionally narrowed to some scopes
+func (m *PasetoPublicMaker) CreateToken(username string, role string, duration time.Duration, scopes ...string) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration, scopes...)
+	if err != nil {
+		return "", payload, err
+	}
+	m.claims.stamp(payload)
+
+	keyID, privateKey := m.keyring.ActiveKey()
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", payload, err
+	}
+
+	footer, err := json.Marshal(pasetoFooter{KeyID: keyID})
+	if err != nil {
+		return "", payload, err
+	}
+
+	signature := ed25519.Sign(privateKey.(ed25519.PrivateKey), preAuthEncode([]byte(_pasetoV4PublicHeader), message, footer, nil))
+
+	token := _pasetoV4PublicHeader +
+		base64.RawURLEncoding.EncodeToString(append(message, signature...)) +
+		"." + base64.RawURLEncoding.EncodeToString(footer)
+
+	return token, payload, nil
+}
+
+// VerifyToken checks if the token is valid or not
+func (m *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	if !strings.HasPrefix(token, _pasetoV4PublicHeader) {
+		return nil, ErrInvalidToken
+	}
+
+	body, encodedFooter, _ := strings.Cut(strings.TrimPrefix(token, _pasetoV4PublicHeader), ".")
+
+	signed, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil || len(signed) < ed25519.SignatureSize {
+		return nil, ErrInvalidToken
+	}
+
+	footer, err := base64.RawURLEncoding.DecodeString(encodedFooter)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var parsedFooter pasetoFooter
+	if len(footer) > 0 {
+		err = json.Unmarshal(footer, &parsedFooter)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	publicKey, ok := m.keyring.PublicKey(parsedFooter.KeyID)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	message := signed[:len(signed)-ed25519.SignatureSize]
+	signature := signed[len(signed)-ed25519.SignatureSize:]
+
+	if !ed25519.Verify(publicKey.(ed25519.PublicKey), preAuthEncode([]byte(_pasetoV4PublicHeader), message, footer, nil), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	err = json.Unmarshal(message, payload)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.claims.verify(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// PublicKeys returns the keys verifying the tokens of the maker
+func (m *PasetoPublicMaker) PublicKeys() JSONWebKeySet {
+	return m.keyring.jwks(SigningMethodEdDSA.Alg())
+}
+
+// preAuthEncode is the PAE function of the PASETO spec, it binds every piece to its length
+// so the header, message, footer and implicit assertion can't be shifted into each other
+func preAuthEncode(pieces ...[]byte) []byte {
+	encoded := binary.LittleEndian.AppendUint64(nil, uint64(len(pieces)))
+
+	for _, piece := range pieces {
+		encoded = binary.LittleEndian.AppendUint64(encoded, uint64(len(piece)))
+		encoded = append(encoded, piece...)
+	}
+
+	return encoded
+}
@@ -1,6 +1,7 @@
 package token
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
 	"time"
@@ -11,37 +12,158 @@ var (
 	ErrInvalidToken = errors.New("token is invalid")
 )
 
+// _clockSkew tolerates the clock of the verifying instance running slightly behind the issuing one
+const _clockSkew = 30 * time.Second
+
 // Payload contains the data of the token
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Issuer    string    `json:"iss,omitempty"`
+	Audience  []string  `json:"aud,omitempty"`
+	Scopes    []string  `json:"scopes,omitempty"`
 	IssuedAt  time.Time `json:"issued_at"`
+	NotBefore time.Time `json:"-"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
 
-// NewPayload creates a new token payload with a specific username and duration
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+// NewPayload creates a new token payload with a specific username, role and duration.
+// Scopes narrow down what the token grants, a token without scopes is unrestricted.
+func NewPayload(username string, role string, duration time.Duration, scopes ...string) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
 	payload := &Payload{
 		ID:        tokenID,
 		Username:  username,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		Role:      role,
+		Scopes:    scopes,
+		IssuedAt:  now,
+		NotBefore: now,
+		ExpiredAt: now.Add(duration),
 	}
 
 	return payload, nil
 }
 
+// payloadAlias has the fields of Payload without its JSON methods
+type payloadAlias Payload
+
+// registeredClaims are the RFC 7519 time claims, external verifiers expect them as NumericDate i.e. unix seconds.
+// issued_at and expired_at are kept next to iat and exp for the clients reading them.
+type registeredClaims struct {
+	IssuedAt  int64 `json:"iat"`
+	NotBefore int64 `json:"nbf"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// MarshalJSON encodes the time claims registered by RFC 7519 as NumericDate
+func (p Payload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		payloadAlias
+		registeredClaims
+	}{
+		payloadAlias: payloadAlias(p),
+		registeredClaims: registeredClaims{
+			IssuedAt:  p.IssuedAt.Unix(),
+			NotBefore: p.NotBefore.Unix(),
+			ExpiresAt: p.ExpiredAt.Unix(),
+		},
+	})
+}
+
+// UnmarshalJSON reads the not before claim from its NumericDate, or from the RFC 3339 string
+// of the tokens issued before it was encoded as such. iat and exp are ignored since issued_at
+// and expired_at are more precise.
+func (p *Payload) UnmarshalJSON(data []byte) error {
+	var decoded struct {
+		payloadAlias
+		NotBefore json.RawMessage `json:"nbf"`
+	}
+
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	*p = Payload(decoded.payloadAlias)
+	if len(decoded.NotBefore) == 0 {
+		return nil
+	}
+
+	var notBefore int64
+	if err = json.Unmarshal(decoded.NotBefore, &notBefore); err == nil {
+		p.NotBefore = time.Unix(notBefore, 0)
+		return nil
+	}
+
+	return json.Unmarshal(decoded.NotBefore, &p.NotBefore)
+}
+
 // Valid check if the token is valid or not
 func (p *Payload) Valid() error {
-	if time.Now().After(p.ExpiredAt) {
+	now := time.Now()
+
+	if now.After(p.ExpiredAt) {
 		return ErrExpiredToken
 	}
 
+	if now.Add(_clockSkew).Before(p.NotBefore) {
+		return ErrInvalidToken
+	}
+
 	return nil
 }
+
+// IsMfaPending check if the token only proves the password and still waits for the second factor
+func (p *Payload) IsMfaPending() bool {
+	return p.hasMarker(ScopeMfaPending)
+}
+
+// IsRefresh check if the token is a refresh token, it can only renew the access token of its session
+func (p *Payload) IsRefresh() bool {
+	return p.hasMarker(ScopeRefresh)
+}
+
+// AccessScopes returns the scopes the token grants without the refresh marker,
+// a renewal copies them to the new tokens of the session
+func (p *Payload) AccessScopes() []string {
+	var scopes []string
+	for _, s := range p.Scopes {
+		if s != ScopeRefresh {
+			scopes = append(scopes, s)
+		}
+	}
+
+	return scopes
+}
+
+func (p *Payload) hasMarker(marker string) bool {
+	for _, s := range p.Scopes {
+		if s == marker {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasScope check if the token grants the provided scope
+func (p *Payload) HasScope(scope string) bool {
+	if len(p.Scopes) == 0 {
+		return true
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package apikeyutil
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	_keyTag       = "sbk"
+	_prefixLength = 6
+	_secretLength = 32
+)
+
+var ErrInvalidKey = errors.New("invalid api key")
+
+// Generate creates a new api key formatted as sbk_<prefix>_<secret> and returns it with its prefix.
+// The prefix is public and used to look the key up, only the hash of the whole key is stored.
+func Generate() (key string, prefix string, err error) {
+	prefixBytes := make([]byte, _prefixLength)
+	if _, err = rand.Read(prefixBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	secretBytes := make([]byte, _secretLength)
+	if _, err = rand.Read(secretBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	key = fmt.Sprintf("%s_%s_%s", _keyTag, prefix, base64.RawURLEncoding.EncodeToString(secretBytes))
+
+	return key, prefix, nil
+}
+
+// Prefix extracts the lookup prefix of the key
+func Prefix(key string) (string, error) {
+	fields := strings.SplitN(key, "_", 3)
+	if len(fields) != 3 || fields[0] != _keyTag || len(fields[1]) != 2*_prefixLength || len(fields[2]) == 0 {
+		return "", ErrInvalidKey
+	}
+
+	return fields[1], nil
+}
+
+// Hash returns the sha256 hash of the key, a slow hash isn't needed since the key is random
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Check check if the key matches the stored hash
+func Check(key string, hashedKey string) error {
+	if subtle.ConstantTimeCompare([]byte(Hash(key)), []byte(hashedKey)) != 1 {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package apikeyutil
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestApiKey(t *testing.T) {
+	key1, prefix1, err := Generate()
+	require.NoError(t, err)
+	require.NotEmpty(t, key1)
+
+	prefix, err := Prefix(key1)
+	require.NoError(t, err)
+	require.Equal(t, prefix1, prefix)
+
+	hashedKey := Hash(key1)
+	require.NoError(t, Check(key1, hashedKey))
+
+	key2, prefix2, err := Generate()
+	require.NoError(t, err)
+	require.NotEqual(t, key1, key2)
+	require.NotEqual(t, prefix1, prefix2)
+	require.ErrorIs(t, Check(key2, hashedKey), ErrInvalidKey)
+}
+
+func TestPrefixInvalidKey(t *testing.T) {
+	for _, key := range []string{"", "sbk", "sbk_abc_secret", "xyz_0123456789ab_secret", "sbk_0123456789ab_"} {
+		_, err := Prefix(key)
+		require.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,19 @@
+package currencyutil
+
+// Currency constants
+const (
+	USD = "USD"
+	EUR = "EUR"
+	CAD = "CAD"
+	VND = "VND"
+)
+
+// IsSupportCurrency check if the provided currency is supported or not
+func IsSupportCurrency(currency string) bool {
+	switch currency {
+	case CAD, USD, EUR, VND:
+		return true
+	}
+
+	return false
+}
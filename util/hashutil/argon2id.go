@@ -0,0 +1,119 @@
+package hashutil
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"strings"
+)
+
+const (
+	_argon2idSaltLength = 16
+	_argon2idKeyLength  = 32
+)
+
+// Argon2idParams are the cost parameters of argon2id, see RFC 9106
+type Argon2idParams struct {
+	// Memory is in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultArgon2idParams follow the OWASP recommendation for argon2id
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+}
+
+// Argon2idHasher hashes passwords with argon2id, its hashes are in the PHC string format
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher creates a new Argon2idHasher
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, _argon2idSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, _argon2idKeyLength)
+
+	return fmt.Sprintf(
+		"$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		Argon2idAlgorithm, argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hashedPassword string) bool {
+	decoded, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	return decoded.params != h.params || len(decoded.key) != _argon2idKeyLength
+}
+
+type argon2idHash struct {
+	params Argon2idParams
+	salt   []byte
+	key    []byte
+}
+
+func decodeArgon2id(hashedPassword string) (argon2idHash, error) {
+	var decoded argon2idHash
+
+	fields := strings.Split(hashedPassword, "$")
+	if len(fields) != 6 || fields[0] != "" || fields[1] != Argon2idAlgorithm {
+		return decoded, ErrUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(fields[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return decoded, ErrUnsupportedHash
+	}
+
+	_, err := fmt.Sscanf(fields[3], "m=%d,t=%d,p=%d", &decoded.params.Memory, &decoded.params.Iterations, &decoded.params.Parallelism)
+	if err != nil {
+		return decoded, ErrUnsupportedHash
+	}
+
+	decoded.salt, err = base64.RawStdEncoding.DecodeString(fields[4])
+	if err != nil {
+		return decoded, ErrUnsupportedHash
+	}
+
+	decoded.key, err = base64.RawStdEncoding.DecodeString(fields[5])
+	if err != nil || len(decoded.key) == 0 {
+		return decoded, ErrUnsupportedHash
+	}
+
+	return decoded, nil
+}
+
+func checkArgon2id(password, hashedPassword string) error {
+	decoded, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	key := argon2.IDKey(
+		[]byte(password), decoded.salt,
+		decoded.params.Iterations, decoded.params.Memory, decoded.params.Parallelism, uint32(len(decoded.key)),
+	)
+	if subtle.ConstantTimeCompare(key, decoded.key) != 1 {
+		return ErrMismatchedPassword
+	}
+
+	return nil
+}
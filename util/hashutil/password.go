@@ -1,21 +1,88 @@
 package hashutil
 
 import (
+	"errors"
 	"fmt"
+	"github.com/thehaung/simplebank/config"
 	"golang.org/x/crypto/bcrypt"
+	"strings"
 )
 
-// HashPassword return the bcrypt hash of the password
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
+// Supported hashing algorithms
+const (
+	BcryptAlgorithm   = "bcrypt"
+	Argon2idAlgorithm = "argon2id"
+)
+
+// ErrMismatchedPassword is returned by CheckPassword when the password is wrong, whatever the algorithm.
+// It's the bcrypt error so callers comparing with it keep working.
+var ErrMismatchedPassword = bcrypt.ErrMismatchedHashAndPassword
+
+var ErrUnsupportedHash = errors.New("unsupported password hash")
+
+// Hasher hashes passwords into strings carrying the algorithm and its parameters,
+// so CheckPassword can verify them after the parameters changed
+type Hasher interface {
+	// Hash returns the encoded hash of the password
+	Hash(password string) (string, error)
+	// NeedsRehash reports whether the hash was made with another algorithm or other parameters
+	NeedsRehash(hashedPassword string) bool
+}
+
+// _defaultHasher is used by HashPassword, it's the hasher every stored password used before they were configurable
+var _defaultHasher Hasher = NewBcryptHasher(bcrypt.DefaultCost)
+
+// NewHasherFromConfig creates the hasher selected in the application config
+func NewHasherFromConfig(cfg *config.Config) (Hasher, error) {
+	switch cfg.PasswordHasher {
+	case "", BcryptAlgorithm:
+		cost := cfg.BcryptCost
+		if cost == 0 {
+			cost = bcrypt.DefaultCost
+		}
+
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+
+		return NewBcryptHasher(cost), nil
+	case Argon2idAlgorithm:
+		params := DefaultArgon2idParams
+		if cfg.Argon2Memory > 0 {
+			params.Memory = cfg.Argon2Memory
+		}
+		if cfg.Argon2Iterations > 0 {
+			params.Iterations = cfg.Argon2Iterations
+		}
+		if cfg.Argon2Parallelism > 0 {
+			params.Parallelism = cfg.Argon2Parallelism
+		}
+
+		return NewArgon2idHasher(params), nil
 	}
 
-	return string(hashedPassword), nil
+	return nil, fmt.Errorf("unsupported password hasher %s", cfg.PasswordHasher)
+}
+
+// HashPassword return the hash of the password made by the default bcrypt hasher
+func HashPassword(password string) (string, error) {
+	return _defaultHasher.Hash(password)
 }
 
-// CheckPassword check if the provided password is correct or not
+// CheckPassword check if the provided password is correct or not, the algorithm is read from the hash
 func CheckPassword(password, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	switch {
+	case strings.HasPrefix(hashedPassword, "$"+Argon2idAlgorithm+"$"):
+		return checkArgon2id(password, hashedPassword)
+	case isBcryptHash(hashedPassword):
+		return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	}
+
+	return ErrUnsupportedHash
+}
+
+// CheckDummyPassword spends as much time as checking a password hashed by the hasher, it's used when the user
+// doesn't exist so that an unknown username can't be told from a wrong password by the response time
+func CheckDummyPassword(hasher Hasher, password string) {
+	_, _ = hasher.Hash(password)
 }
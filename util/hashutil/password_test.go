@@ -2,6 +2,7 @@ package hashutil
 
 import (
 	"github.com/stretchr/testify/require"
+	"github.com/thehaung/simplebank/config"
 	"github.com/thehaung/simplebank/util/randutil"
 	"golang.org/x/crypto/bcrypt"
 	"testing"
@@ -31,3 +32,69 @@ func BenchmarkPassword(b *testing.B) {
 		_ = CheckPassword("secret", "$2a$10$I00w.S7ELh37J1UpRsGYruqVgAM5jGsQUxSfrNmmqflYytXf4CVY2")
 	}
 }
+
+func TestArgon2idHasher(t *testing.T) {
+	params := Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+	hasher := NewArgon2idHasher(params)
+
+	password := randutil.StringWithQuantity(6)
+	hashedPassword1, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, hashedPassword1)
+
+	require.NoError(t, CheckPassword(password, hashedPassword1))
+	require.ErrorIs(t, CheckPassword(randutil.StringWithQuantity(6), hashedPassword1), ErrMismatchedPassword)
+
+	hashedPassword2, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.NotEqual(t, hashedPassword1, hashedPassword2)
+
+	require.False(t, hasher.NeedsRehash(hashedPassword1))
+	require.True(t, NewArgon2idHasher(Argon2idParams{Memory: 2048, Iterations: 1, Parallelism: 1}).NeedsRehash(hashedPassword1))
+
+	bcryptHash, err := HashPassword(password)
+	require.NoError(t, err)
+	require.True(t, hasher.NeedsRehash(bcryptHash))
+}
+
+func TestBcryptHasher(t *testing.T) {
+	hasher := NewBcryptHasher(bcrypt.MinCost)
+
+	password := randutil.StringWithQuantity(6)
+	hashedPassword, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.NoError(t, CheckPassword(password, hashedPassword))
+
+	require.False(t, hasher.NeedsRehash(hashedPassword))
+	require.True(t, NewBcryptHasher(bcrypt.DefaultCost).NeedsRehash(hashedPassword))
+
+	argon2idHash, err := NewArgon2idHasher(Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}).Hash(password)
+	require.NoError(t, err)
+	require.True(t, hasher.NeedsRehash(argon2idHash))
+}
+
+func TestCheckPasswordUnsupportedHash(t *testing.T) {
+	require.ErrorIs(t, CheckPassword("secret", "plain-text"), ErrUnsupportedHash)
+	require.ErrorIs(t, CheckPassword("secret", "$argon2id$v=19$m=1024$salt$key"), ErrUnsupportedHash)
+	require.ErrorIs(t, CheckPassword("secret", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5"), ErrUnsupportedHash)
+}
+
+func TestNewHasherFromConfig(t *testing.T) {
+	hasher, err := NewHasherFromConfig(&config.Config{})
+	require.NoError(t, err)
+	require.Equal(t, NewBcryptHasher(bcrypt.DefaultCost), hasher)
+
+	hasher, err = NewHasherFromConfig(&config.Config{PasswordHasher: BcryptAlgorithm, BcryptCost: 12})
+	require.NoError(t, err)
+	require.Equal(t, NewBcryptHasher(12), hasher)
+
+	_, err = NewHasherFromConfig(&config.Config{PasswordHasher: BcryptAlgorithm, BcryptCost: 50})
+	require.Error(t, err)
+
+	hasher, err = NewHasherFromConfig(&config.Config{PasswordHasher: Argon2idAlgorithm, Argon2Iterations: 4})
+	require.NoError(t, err)
+	require.Equal(t, NewArgon2idHasher(Argon2idParams{Memory: 64 * 1024, Iterations: 4, Parallelism: 2}), hasher)
+
+	_, err = NewHasherFromConfig(&config.Config{PasswordHasher: "md5"})
+	require.Error(t, err)
+}
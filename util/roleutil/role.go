@@ -0,0 +1,23 @@
+package roleutil
+
+// Role constants, they must match the users_role_check constraint
+const (
+	Depositor = "depositor"
+	Banker    = "banker"
+	Admin     = "admin"
+)
+
+// IsSupportRole check if the provided role is supported or not
+func IsSupportRole(role string) bool {
+	switch role {
+	case Depositor, Banker, Admin:
+		return true
+	}
+
+	return false
+}
+
+// CanViewAnyAccount reports whether the role may read accounts owned by other users
+func CanViewAnyAccount(role string) bool {
+	return role == Banker || role == Admin
+}
@@ -0,0 +1,79 @@
+package scheduleutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	Daily   = "@daily"
+	Weekly  = "@weekly"
+	Monthly = "@monthly"
+	_every  = "@every "
+
+	_minInterval = time.Minute
+)
+
+// Validate check if the provided schedule is supported or not
+func Validate(schedule string) error {
+	now := time.Now()
+	_, err := Next(schedule, now, now, now)
+	return err
+}
+
+// Next returns the first run of the schedule after now, counting periods from the previous run.
+// Runs missed while the scheduler was down are skipped rather than replayed one after another.
+// @monthly keeps the day of month of the start, clamped to the last day of shorter months,
+// so a schedule starting on Jan 31 runs on Feb 28 and then on Mar 31.
+func Next(schedule string, start, previous, now time.Time) (time.Time, error) {
+	step, err := parse(schedule, start)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := step(previous)
+	for !next.After(now) {
+		next = step(next)
+	}
+
+	return next, nil
+}
+
+func parse(schedule string, start time.Time) (func(time.Time) time.Time, error) {
+	switch schedule {
+	case Daily:
+		return func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }, nil
+	case Weekly:
+		return func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }, nil
+	case Monthly:
+		return func(t time.Time) time.Time { return nextMonth(t, start.Day()) }, nil
+	}
+
+	if !strings.HasPrefix(schedule, _every) {
+		return nil, fmt.Errorf("unsupported schedule %q", schedule)
+	}
+
+	interval, err := time.ParseDuration(strings.TrimPrefix(schedule, _every))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+
+	if interval < _minInterval {
+		return nil, fmt.Errorf("invalid schedule %q: interval must be at least %s", schedule, _minInterval)
+	}
+
+	return func(t time.Time) time.Time { return t.Add(interval) }, nil
+}
+
+// nextMonth moves t to the provided day of the following month, or to its last day if the month is shorter.
+// time.AddDate can't be used since it normalizes Jan 31 plus a month to Mar 3.
+func nextMonth(t time.Time, day int) time.Time {
+	year, month, _ := t.Date()
+	lastDay := time.Date(year, month+2, 0, 0, 0, 0, 0, t.Location()).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(year, month+1, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
@@ -0,0 +1,122 @@
+package scheduleutil
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	previous := time.Date(2023, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name     string
+		Schedule string
+		Now      time.Time
+		Expected time.Time
+	}{
+		{
+			Name:     "Daily",
+			Schedule: Daily,
+			Now:      previous,
+			Expected: time.Date(2023, 1, 16, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "Weekly",
+			Schedule: Weekly,
+			Now:      previous,
+			Expected: time.Date(2023, 1, 22, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "Monthly",
+			Schedule: Monthly,
+			Now:      previous,
+			Expected: time.Date(2023, 2, 15, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "Every",
+			Schedule: "@every 36h",
+			Now:      previous,
+			Expected: time.Date(2023, 1, 16, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "SkipMissedRuns",
+			Schedule: Monthly,
+			Now:      time.Date(2023, 4, 20, 0, 0, 0, 0, time.UTC),
+			Expected: time.Date(2023, 5, 15, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			next, err := Next(tc.Schedule, previous, previous, tc.Now)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, next)
+		})
+	}
+}
+
+func TestNextMonthly(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Start    time.Time
+		Previous time.Time
+		Expected time.Time
+	}{
+		{
+			Name:     "EndOfJanuary",
+			Start:    time.Date(2023, 1, 31, 9, 0, 0, 0, time.UTC),
+			Previous: time.Date(2023, 1, 31, 9, 0, 0, 0, time.UTC),
+			Expected: time.Date(2023, 2, 28, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "BackToAnchorDay",
+			Start:    time.Date(2023, 1, 31, 9, 0, 0, 0, time.UTC),
+			Previous: time.Date(2023, 2, 28, 9, 0, 0, 0, time.UTC),
+			Expected: time.Date(2023, 3, 31, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "ThirtyDayMonth",
+			Start:    time.Date(2023, 1, 31, 9, 0, 0, 0, time.UTC),
+			Previous: time.Date(2023, 3, 31, 9, 0, 0, 0, time.UTC),
+			Expected: time.Date(2023, 4, 30, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "EndOfDecember",
+			Start:    time.Date(2023, 1, 31, 9, 0, 0, 0, time.UTC),
+			Previous: time.Date(2023, 12, 31, 9, 0, 0, 0, time.UTC),
+			Expected: time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "LeapDay",
+			Start:    time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+			Previous: time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+			Expected: time.Date(2024, 3, 29, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "LeapDayNextYear",
+			Start:    time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+			Previous: time.Date(2025, 1, 29, 9, 0, 0, 0, time.UTC),
+			Expected: time.Date(2025, 2, 28, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.Name, func(t *testing.T) {
+			next, err := Next(Monthly, tc.Start, tc.Previous, tc.Previous)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, next)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(Daily))
+	require.NoError(t, Validate("@every 1h30m"))
+
+	require.Error(t, Validate("0 9 1 * *"))
+	require.Error(t, Validate("@every soon"))
+	require.Error(t, Validate("@every 1s"))
+}
@@ -0,0 +1,27 @@
+package secretutil
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+const _secretLength = 32
+
+// Generate creates a random url safe secret, e.g. for the single-use links sent to the users
+func Generate() (string, error) {
+	secret := make([]byte, _secretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// Hash returns the sha256 hash the secret is stored as, a slow hash isn't needed since the secret is random
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
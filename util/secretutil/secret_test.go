@@ -0,0 +1,20 @@
+package secretutil
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	secret1, err := Generate()
+	require.NoError(t, err)
+	require.Len(t, secret1, 43)
+
+	secret2, err := Generate()
+	require.NoError(t, err)
+	require.NotEqual(t, secret1, secret2)
+
+	require.Len(t, Hash(secret1), 64)
+	require.Equal(t, Hash(secret1), Hash(secret1))
+	require.NotEqual(t, Hash(secret1), Hash(secret2))
+}